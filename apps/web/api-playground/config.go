@@ -0,0 +1,20 @@
+package main
+
+import (
+	"github.com/dunamismax/go-stdlib/pkg/server"
+	"github.com/dunamismax/go-stdlib/pkg/tracing"
+)
+
+// Config is loaded by config.Load from defaultConfig, an optional file, the
+// environment and flags. Run with -h to list every setting.
+type Config struct {
+	Server  server.Config  `config:"server"`
+	Tracing tracing.Config `config:"tracing"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		Server:  server.Config{Addr: ":8080"}.WithDefaults(),
+		Tracing: tracing.Config{ServiceName: "api-playground"},
+	}
+}
@@ -2,7 +2,15 @@ module github.com/dunamismax/go-stdlib/apps/web/api-playground
 
 go 1.24
 
-require github.com/labstack/echo/v4 v4.12.0
+require (
+	github.com/dunamismax/go-stdlib/pkg/config v0.0.0
+	github.com/dunamismax/go-stdlib/pkg/health v0.0.0
+	github.com/dunamismax/go-stdlib/pkg/metrics v0.0.0
+	github.com/dunamismax/go-stdlib/pkg/middleware v0.0.0
+	github.com/dunamismax/go-stdlib/pkg/server v0.0.0
+	github.com/dunamismax/go-stdlib/pkg/tracing v0.0.0
+	github.com/labstack/echo/v4 v4.12.0
+)
 
 require (
 	github.com/golang-jwt/jwt v3.2.2+incompatible // indirect
@@ -17,3 +25,15 @@ require (
 	golang.org/x/text v0.14.0 // indirect
 	golang.org/x/time v0.5.0 // indirect
 )
+
+replace github.com/dunamismax/go-stdlib/pkg/config => ../../../pkg/config
+
+replace github.com/dunamismax/go-stdlib/pkg/health => ../../../pkg/health
+
+replace github.com/dunamismax/go-stdlib/pkg/metrics => ../../../pkg/metrics
+
+replace github.com/dunamismax/go-stdlib/pkg/middleware => ../../../pkg/middleware
+
+replace github.com/dunamismax/go-stdlib/pkg/server => ../../../pkg/server
+
+replace github.com/dunamismax/go-stdlib/pkg/tracing => ../../../pkg/tracing
@@ -1,19 +1,29 @@
 package main
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"embed"
 	"encoding/base64"
+	"errors"
+	"flag"
 	"fmt"
 	"html/template"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/dunamismax/go-stdlib/pkg/config"
+	"github.com/dunamismax/go-stdlib/pkg/health"
+	"github.com/dunamismax/go-stdlib/pkg/metrics"
+	stdmiddleware "github.com/dunamismax/go-stdlib/pkg/middleware"
+	"github.com/dunamismax/go-stdlib/pkg/server"
+	"github.com/dunamismax/go-stdlib/pkg/tracing"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
@@ -59,21 +69,30 @@ func NewApp() *App {
 	e.HideBanner = true
 	e.Renderer = NewTemplateRenderer()
 
-	// Middleware
-	e.Use(middleware.Logger())
+	// Middleware. The shared access logger assigns request IDs; errors are
+	// rendered inside it so their status codes reach the log.
+	e.Use(echo.WrapMiddleware(stdmiddleware.AccessLog(stdmiddleware.AccessLogConfig{
+		ExcludePaths: []string{"/static/*", "/healthz", "/readyz", "/metrics"},
+	})))
+	e.Use(handleErrors)
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
 	e.Use(middleware.Secure())
-	e.Use(middleware.RequestID())
-
-	// Custom middleware for structured logging
-	e.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
-		Format: "${time_rfc3339} ${id} ${remote_ip} ${host} ${method} ${uri} ${user_agent} ${status} ${error} ${latency} ${latency_human} ${bytes_in} ${bytes_out}\n",
-	}))
 
 	return &App{echo: e}
 }
 
+// handleErrors renders handler errors immediately instead of after the
+// middleware chain returns, as Echo does by default.
+func handleErrors(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := next(c); err != nil {
+			c.Error(err)
+		}
+		return nil
+	}
+}
+
 func (a *App) homePage(c echo.Context) error {
 	return c.Render(http.StatusOK, "index", nil)
 }
@@ -451,13 +470,57 @@ func (a *App) setupRoutes() {
 }
 
 func main() {
+	cfg := defaultConfig()
+	if err := config.Load(&cfg, config.Options{Args: os.Args[1:]}); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		fmt.Fprintln(os.Stderr, "Failed to load configuration:", err)
+		os.Exit(1)
+	}
+
+	tracer, err := tracing.New(cfg.Tracing)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to start tracing:", err)
+		os.Exit(1)
+	}
+	tracing.SetDefault(tracer)
+
 	app := NewApp()
 	app.setupRoutes()
 
-	// Start server
-	app.echo.Logger.Info("API Playground starting on port :8080")
-	if err := app.echo.Start(":8080"); err != nil && err != http.ErrServerClosed {
-		app.echo.Logger.Fatal("Failed to start server:", err)
-		log.Fatal(err)
+	// Probes for the orchestrator; readiness fails while draining
+	probes := health.New(health.Config{})
+	app.echo.GET("/healthz", echo.WrapHandler(http.HandlerFunc(probes.Live)))
+	app.echo.GET("/readyz", echo.WrapHandler(http.HandlerFunc(probes.Ready)))
+	app.echo.GET("/version", echo.WrapHandler(http.HandlerFunc(probes.Version)))
+
+	// Prometheus metrics and tracing. Both middlewares wrap Echo from
+	// outside, so each route reports its pattern once Echo has routed it.
+	registry := metrics.NewRegistry()
+	metrics.RegisterRuntime(registry)
+	httpMetrics := metrics.NewHTTPMetrics(registry)
+	app.echo.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			metrics.SetRoute(c.Request(), c.Path())
+			span := tracing.SpanFromContext(c.Request().Context())
+			span.SetName(c.Request().Method + " " + c.Path())
+			span.SetAttributes(tracing.String("http.route", c.Path()))
+			return next(c)
+		}
+	})
+	app.echo.GET("/metrics", echo.WrapHandler(registry.Handler()))
+
+	// Start server; Echo is an http.Handler, so the shared runner drives it
+	cfg.Server.Handler = tracer.Middleware(nil)(httpMetrics.Middleware(nil)(app.echo))
+	srv := server.New(cfg.Server)
+	probes.AddCheck("server", health.ReadyCheck(srv.Ready))
+	srv.OnShutdown("tracing", tracer.Shutdown)
+
+	slog.Info("Configuration loaded", "config", config.Redacted(&cfg))
+	app.echo.Logger.Info("API Playground starting on " + cfg.Server.Addr)
+	if err := srv.Run(context.Background()); err != nil {
+		app.echo.Logger.Error("Server stopped with errors:", err)
+		os.Exit(1)
 	}
 }
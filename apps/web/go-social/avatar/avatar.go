@@ -0,0 +1,151 @@
+// Package avatar turns uploaded pictures into square profile avatars,
+// keeps them in a Storage and draws identicons for users without one.
+package avatar
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // register the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	_ "golang.org/x/image/webp" // register the WebP decoder
+)
+
+// Sizes are the edge lengths, in pixels, each avatar is stored at, largest
+// first.
+var Sizes = []int{256, 128, 48}
+
+var (
+	// ErrTooLarge is returned for uploads over Config.MaxBytes or
+	// Config.MaxPixels.
+	ErrTooLarge = errors.New("image is too large")
+	// ErrUnsupportedFormat is returned for anything but PNG, JPEG, GIF and
+	// WebP, judged by content rather than file name or declared type.
+	ErrUnsupportedFormat = errors.New("image must be a PNG, JPEG, GIF or WebP")
+	// ErrInvalidImage is returned when a file of a supported format does
+	// not decode.
+	ErrInvalidImage = errors.New("image could not be read")
+)
+
+// formats maps the content types http.DetectContentType sniffs to the
+// formats accepted.
+var formats = map[string]string{
+	"image/png":  "png",
+	"image/jpeg": "jpeg",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+}
+
+// Config says where avatars are stored and how large an upload may be. The
+// zero value accepts pictures up to 5 MiB and 25 megapixels.
+type Config struct {
+	// Dir holds the stored avatars. Default "avatars" inside the data
+	// directory.
+	Dir string `config:"dir" usage:"directory storing uploaded avatars"`
+	// MaxBytes caps the size of an upload. Default 5 MiB.
+	MaxBytes int64 `config:"max_bytes" usage:"largest avatar upload accepted, in bytes"`
+	// MaxPixels caps width × height, which bounds the memory decoding
+	// takes whatever the file size. Default 25 million.
+	MaxPixels int `config:"max_pixels" usage:"largest avatar upload accepted, in pixels (width times height)"`
+}
+
+// WithDefaults fills in the zero fields of c.
+func (c Config) WithDefaults() Config {
+	if c.MaxBytes == 0 {
+		c.MaxBytes = 5 << 20
+	}
+	if c.MaxPixels == 0 {
+		c.MaxPixels = 25_000_000
+	}
+	return c
+}
+
+// Image is a processed avatar: one encoded file per entry of Sizes.
+type Image struct {
+	// Ext is "png" for images with transparency and "jpg" otherwise.
+	Ext   string
+	Files map[int][]byte
+}
+
+// Process reads an upload of at most config.MaxBytes and renders it at
+// each of Sizes: cropped to its centered square, scaled, turned upright as
+// its EXIF orientation says and re-encoded. Re-encoding drops EXIF and all
+// other metadata, such as the location a photo was taken at. Animated GIFs
+// keep their first frame.
+func Process(r io.Reader, config Config) (*Image, error) {
+	config = config.WithDefaults()
+
+	data, err := io.ReadAll(io.LimitReader(r, config.MaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if int64(len(data)) > config.MaxBytes {
+		return nil, ErrTooLarge
+	}
+	format, ok := formats[http.DetectContentType(data)]
+	if !ok {
+		return nil, ErrUnsupportedFormat
+	}
+
+	// Check the dimensions in the header before decoding allocates for them
+	header, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if header.Width <= 0 || header.Height <= 0 {
+		return nil, ErrInvalidImage
+	}
+	if header.Width > config.MaxPixels/header.Height {
+		return nil, ErrTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	img := resize(src, centerSquare(src.Bounds()), Sizes[0])
+	if format == "jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+
+	result := &Image{Ext: "jpg", Files: make(map[int][]byte, len(Sizes))}
+	if !img.Opaque() {
+		result.Ext = "png"
+	}
+	for _, size := range Sizes {
+		scaled := img
+		if size != Sizes[0] {
+			scaled = resize(img, img.Bounds(), size)
+		}
+
+		var buf bytes.Buffer
+		if result.Ext == "png" {
+			err = png.Encode(&buf, scaled)
+		} else {
+			err = jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: 85})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode avatar: %w", err)
+		}
+		result.Files[size] = buf.Bytes()
+	}
+	return result, nil
+}
+
+// Fit returns the smallest of Sizes at least size pixels across, or the
+// largest.
+func Fit(size int) int {
+	best := Sizes[0]
+	for _, s := range Sizes {
+		if s >= size && s < best {
+			best = s
+		}
+	}
+	return best
+}
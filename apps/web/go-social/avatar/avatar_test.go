@@ -0,0 +1,206 @@
+package avatar
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+var (
+	red  = color.RGBA{0xFF, 0, 0, 0xFF}
+	blue = color.RGBA{0, 0, 0xFF, 0xFF}
+)
+
+// halves returns a w×h image, red on the left half and blue on the right.
+func halves(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			if x < w/2 {
+				img.Set(x, y, red)
+			} else {
+				img.Set(x, y, blue)
+			}
+		}
+	}
+	return img
+}
+
+func encode(t *testing.T, img image.Image, format string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withOrientation inserts an EXIF segment with the given orientation after
+// the start-of-image marker of a JPEG.
+func withOrientation(data []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01")
+	tiff = binary.BigEndian.AppendUint16(tiff, 0x0112)
+	tiff = append(tiff, 0, 3, 0, 0, 0, 1) // SHORT, one value
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0) // padding, no next directory
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, 0xFF, 0xE1)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(segment)+2))
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+// isRed reports whether c is closer to red than to blue.
+func isRed(c color.Color) bool {
+	r, _, b, _ := c.RGBA()
+	return r > b
+}
+
+func TestProcess(t *testing.T) {
+	transparent := image.NewNRGBA(image.Rect(0, 0, 30, 30))
+	// A 1×1 lossless WebP with an alpha channel; the standard library has
+	// no WebP encoder to make one
+	webp, _ := base64.StdEncoding.DecodeString("UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA==")
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantExt string
+		wantErr error
+	}{
+		{"png", encode(t, halves(300, 200), "png"), "jpg", nil},
+		{"jpeg", encode(t, halves(200, 300), "jpeg"), "jpg", nil},
+		{"gif", encode(t, halves(64, 64), "gif"), "jpg", nil},
+		{"webp", webp, "png", nil},
+		{"small png is scaled up", encode(t, halves(10, 10), "png"), "jpg", nil},
+		{"transparent png", encode(t, transparent, "png"), "png", nil},
+		{"text", []byte("hello, world"), "", ErrUnsupportedFormat},
+		{"svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`), "", ErrUnsupportedFormat},
+		{"truncated png", encode(t, halves(20, 20), "png")[:30], "", ErrInvalidImage},
+		{"too many bytes", append(encode(t, halves(8, 8), "png"), make([]byte, 64<<10)...), "", ErrTooLarge},
+		{"too many pixels", encode(t, image.NewGray(image.Rect(0, 0, 1000, 1001)), "png"), "", ErrTooLarge},
+	}
+
+	config := Config{MaxBytes: 64 << 10, MaxPixels: 1000 * 1000}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := Process(bytes.NewReader(tt.data), config)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Process() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if img.Ext != tt.wantExt {
+				t.Errorf("Process() Ext = %q, want %q", img.Ext, tt.wantExt)
+			}
+			for _, size := range Sizes {
+				out, _, err := image.Decode(bytes.NewReader(img.Files[size]))
+				if err != nil {
+					t.Fatalf("size %d does not decode: %v", size, err)
+				}
+				if b := out.Bounds(); b.Dx() != size || b.Dy() != size {
+					t.Errorf("size %d is %v", size, b)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessOrientation(t *testing.T) {
+	// The centered square of a red|blue image keeps red on the left; EXIF
+	// orientation 6 asks for a clockwise quarter turn, which puts red on top.
+	tests := []struct {
+		orientation uint16
+		wantRedAt   image.Point
+	}{
+		{1, image.Pt(20, 128)},
+		{6, image.Pt(128, 20)},
+		{8, image.Pt(128, 235)},
+		{3, image.Pt(235, 128)},
+	}
+
+	data := encode(t, halves(400, 200), "jpeg")
+	for _, tt := range tests {
+		img, err := Process(bytes.NewReader(withOrientation(data, tt.orientation)), Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := jpeg.Decode(bytes.NewReader(img.Files[256]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !isRed(out.At(tt.wantRedAt.X, tt.wantRedAt.Y)) {
+			t.Errorf("orientation %d: pixel at %v is not red", tt.orientation, tt.wantRedAt)
+		}
+	}
+}
+
+func TestProcessStripsMetadata(t *testing.T) {
+	data := withOrientation(encode(t, halves(64, 64), "jpeg"), 1)
+	img, err := Process(bytes.NewReader(data), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for size, file := range img.Files {
+		if bytes.Contains(file, []byte("Exif")) {
+			t.Errorf("size %d still carries EXIF", size)
+		}
+	}
+}
+
+func TestURL(t *testing.T) {
+	tests := []struct {
+		username  string
+		avatarURL string
+		size      int
+		want      string
+	}{
+		{"ann", "", 48, "/identicons/ann.png?s=48"},
+		{"ann", "", 100, "/identicons/ann.png?s=128"},
+		{"ann", "/avatars/1-ab.jpg", 512, "/avatars/1-ab.jpg?s=256"},
+	}
+	for _, tt := range tests {
+		if got := URL(tt.username, tt.avatarURL, tt.size); got != tt.want {
+			t.Errorf("URL(%q, %q, %d) = %q, want %q", tt.username, tt.avatarURL, tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestIdenticon(t *testing.T) {
+	a := encode(t, Identicon("ann", 128), "png")
+	if !bytes.Equal(a, encode(t, Identicon("ann", 128), "png")) {
+		t.Error("Identicon() differs between calls with the same seed")
+	}
+	if bytes.Equal(a, encode(t, Identicon("bob", 128), "png")) {
+		t.Error("Identicon() is the same for different seeds")
+	}
+
+	img := Identicon(strings.Repeat("x", 8), 100)
+	for y := range 100 {
+		for x := range 50 {
+			if img.ColorIndexAt(x, y) != img.ColorIndexAt(99-x, y) {
+				t.Fatalf("Identicon() is not mirrored at (%d, %d)", x, y)
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package avatar
+
+import (
+	"crypto/sha256"
+	"image"
+	"image/color"
+)
+
+// Identicon draws a size×size default avatar for seed, typically a
+// username: a 5×5 grid of cells, mirrored left to right, on a light
+// background. Which cells are filled, and their color, come from a hash
+// of seed, so the same seed always gets the same picture.
+func Identicon(seed string, size int) *image.Paletted {
+	sum := sha256.Sum256([]byte(seed))
+
+	// Keep every channel in the middle of its range so the color reads on
+	// the background
+	fg := color.RGBA{64 + sum[29]/2, 64 + sum[30]/2, 64 + sum[31]/2, 0xFF}
+	bg := color.RGBA{0xF0, 0xF0, 0xF0, 0xFF}
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{bg, fg})
+
+	const grid = 5
+	cell := size * 4 / 5 / grid
+	margin := (size - cell*grid) / 2
+	for row := range grid {
+		for col := range (grid + 1) / 2 {
+			if sum[row*3+col]&1 == 0 {
+				continue
+			}
+			for _, c := range []int{col, grid - 1 - col} {
+				x, y := margin+c*cell, margin+row*cell
+				for py := y; py < y+cell; py++ {
+					for px := x; px < x+cell; px++ {
+						img.SetColorIndex(px, py, 1)
+					}
+				}
+			}
+		}
+	}
+	return img
+}
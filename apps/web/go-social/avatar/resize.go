@@ -0,0 +1,97 @@
+package avatar
+
+import (
+	"image"
+	"image/draw"
+)
+
+// centerSquare returns the largest square centered in b.
+func centerSquare(b image.Rectangle) image.Rectangle {
+	side := min(b.Dx(), b.Dy())
+	x := b.Min.X + (b.Dx()-side)/2
+	y := b.Min.Y + (b.Dy()-side)/2
+	return image.Rect(x, y, x+side, y+side)
+}
+
+// resize scales the square r of src to size×size pixels. Shrinking
+// averages the source pixels under each destination pixel (a box filter),
+// reading src one row at a time so a large upload needs no full-size copy;
+// growing repeats the nearest source pixel.
+func resize(src image.Image, r image.Rectangle, size int) *image.RGBA {
+	side := r.Dx()
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+
+	// One source row at a time, converted to premultiplied RGBA, which
+	// averages correctly across transparent edges
+	row := image.NewRGBA(image.Rect(0, 0, side, 1))
+	readRow := func(y int) {
+		draw.Draw(row, row.Bounds(), src, image.Pt(r.Min.X, r.Min.Y+y), draw.Src)
+	}
+
+	if side < size {
+		for y := range size {
+			readRow(y * side / size)
+			for x := range size {
+				copy(dst.Pix[dst.PixOffset(x, y):][:4], row.Pix[(x*side/size)*4:][:4])
+			}
+		}
+		return dst
+	}
+
+	sums := make([]uint64, size*size*4)
+	counts := make([]uint64, size*size)
+	for sy := range side {
+		readRow(sy)
+		dy := sy * size / side
+		for sx := range side {
+			d := dy*size + sx*size/side
+			p := row.Pix[sx*4:][:4]
+			sums[d*4] += uint64(p[0])
+			sums[d*4+1] += uint64(p[1])
+			sums[d*4+2] += uint64(p[2])
+			sums[d*4+3] += uint64(p[3])
+			counts[d]++
+		}
+	}
+	for d, n := range counts {
+		for c := range 4 {
+			dst.Pix[d*4+c] = uint8(sums[d*4+c] / n)
+		}
+	}
+	return dst
+}
+
+// orient turns the square img upright from the EXIF orientation its
+// source was stored in. Cropping to the centered square commutes with
+// every orientation, so it can be applied after scaling.
+func orient(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	n := img.Bounds().Dx() - 1
+	dst := image.NewRGBA(img.Bounds())
+	for y := range n + 1 {
+		for x := range n + 1 {
+			sx, sy := x, y
+			switch orientation {
+			case 2: // flip horizontally
+				sx = n - x
+			case 3: // rotate 180°
+				sx, sy = n-x, n-y
+			case 4: // flip vertically
+				sy = n - y
+			case 5: // transpose
+				sx, sy = y, x
+			case 6: // rotate 90° clockwise
+				sx, sy = y, n-x
+			case 7: // transverse
+				sx, sy = n-y, n-x
+			case 8: // rotate 90° counter-clockwise
+				sx, sy = n-y, x
+			}
+			dst.SetRGBA(x, y, img.RGBAAt(sx, sy))
+		}
+	}
+	return dst
+}
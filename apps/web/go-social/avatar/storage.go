@@ -0,0 +1,84 @@
+package avatar
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Storage keeps avatar files by name. Names are single path elements such
+// as "42-9f86d081884c7d65-256.jpg". Open and Delete report a missing file
+// with an error matching fs.ErrNotExist.
+type Storage interface {
+	Put(ctx context.Context, name string, r io.Reader) error
+	Open(ctx context.Context, name string) (io.ReadSeekCloser, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// Disk is a Storage in a directory on the local file system.
+type Disk struct {
+	dir string
+}
+
+// NewDisk returns a Disk storing files in dir, creating it if needed.
+func NewDisk(dir string) (*Disk, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create avatar directory: %w", err)
+	}
+	return &Disk{dir: dir}, nil
+}
+
+// path resolves name inside the directory, refusing anything that is not
+// a plain file name.
+func (d *Disk) path(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || !filepath.IsLocal(name) {
+		return "", fmt.Errorf("invalid avatar file name %q: %w", name, fs.ErrInvalid)
+	}
+	return filepath.Join(d.dir, name), nil
+}
+
+// Put writes to a temporary file first, so readers never see a partial
+// file.
+func (d *Disk) Put(ctx context.Context, name string, r io.Reader) error {
+	path, err := d.path(name)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(d.dir, name+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create avatar file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write avatar file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write avatar file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to move avatar file into place: %w", err)
+	}
+	return nil
+}
+
+func (d *Disk) Open(ctx context.Context, name string) (io.ReadSeekCloser, error) {
+	path, err := d.path(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (d *Disk) Delete(ctx context.Context, name string) error {
+	path, err := d.path(name)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
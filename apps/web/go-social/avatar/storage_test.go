@@ -0,0 +1,89 @@
+package avatar
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestDisk(t *testing.T) {
+	ctx := context.Background()
+	disk, err := NewDisk(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := disk.Put(ctx, "a.png", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	f, err := disk.Open(ctx, "a.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(f)
+	f.Close()
+	if string(got) != "hello" {
+		t.Errorf("Open() read %q, want %q", got, "hello")
+	}
+
+	if err := disk.Delete(ctx, "a.png"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := disk.Open(ctx, "a.png"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Open() after Delete error = %v, want fs.ErrNotExist", err)
+	}
+
+	for _, name := range []string{"", "..", "../a.png", "dir/a.png", "/etc/passwd"} {
+		if err := disk.Put(ctx, name, strings.NewReader("x")); !errors.Is(err, fs.ErrInvalid) {
+			t.Errorf("Put(%q) error = %v, want fs.ErrInvalid", name, err)
+		}
+	}
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	disk, err := NewDisk(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(disk, Config{})
+
+	avatarURL, err := store.Save(ctx, 7, bytes.NewReader(encode(t, halves(300, 300), "png")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, ok := strings.CutPrefix(avatarURL, URLPrefix)
+	if !ok || !strings.HasPrefix(file, "7-") || !strings.HasSuffix(file, ".jpg") {
+		t.Fatalf("Save() = %q, want %s7-<hash>.jpg", avatarURL, URLPrefix)
+	}
+
+	for _, size := range []int{48, 100, 1000} {
+		f, contentType, err := store.Open(ctx, file, size)
+		if err != nil {
+			t.Fatalf("Open(%d) error = %v", size, err)
+		}
+		f.Close()
+		if contentType != "image/jpeg" {
+			t.Errorf("Open(%d) content type = %q, want image/jpeg", size, contentType)
+		}
+	}
+	for _, file := range []string{"7-zz.jpg", "7-ab.gif", "../7-ab.jpg", "noext"} {
+		if _, _, err := store.Open(ctx, file, 48); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Open(%q) error = %v, want fs.ErrNotExist", file, err)
+		}
+	}
+
+	if err := store.Delete(ctx, avatarURL); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.Open(ctx, file, 48); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Open() after Delete error = %v, want fs.ErrNotExist", err)
+	}
+	if err := store.Delete(ctx, "https://example.com/a.jpg"); err != nil {
+		t.Errorf("Delete(foreign URL) error = %v, want nil", err)
+	}
+}
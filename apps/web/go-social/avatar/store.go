@@ -0,0 +1,119 @@
+package avatar
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// URLPrefix is the path avatars are served under.
+const URLPrefix = "/avatars/"
+
+// contentTypes are the types of the files Process encodes, by extension.
+var contentTypes = map[string]string{
+	"jpg": "image/jpeg",
+	"png": "image/png",
+}
+
+// Store processes uploads and keeps the results in a Storage.
+type Store struct {
+	storage Storage
+	config  Config
+}
+
+func NewStore(storage Storage, config Config) *Store {
+	return &Store{storage: storage, config: config.WithDefaults()}
+}
+
+// Save processes an upload for userID, stores it at every size and returns
+// the URL to record as the user's avatar. The file names include a hash of
+// the content, so each upload gets a new URL and the files can be cached
+// for good.
+func (s *Store) Save(ctx context.Context, userID int, r io.Reader) (string, error) {
+	img, err := Process(r, s.config)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(img.Files[Sizes[0]])
+	id := fmt.Sprintf("%d-%x", userID, sum[:8])
+	for _, size := range Sizes {
+		if err := s.storage.Put(ctx, fileName(id, size, img.Ext), bytes.NewReader(img.Files[size])); err != nil {
+			return "", err
+		}
+	}
+	return URLPrefix + id + "." + img.Ext, nil
+}
+
+// Open returns the stored file behind a URL from Save, with the URL prefix
+// removed, at the stored size that best fits size, and its content type.
+// Names Save did not issue fail with fs.ErrNotExist.
+func (s *Store) Open(ctx context.Context, file string, size int) (io.ReadSeekCloser, string, error) {
+	id, ext, ok := parseFile(file)
+	if !ok {
+		return nil, "", fs.ErrNotExist
+	}
+	f, err := s.storage.Open(ctx, fileName(id, Fit(size), ext))
+	if err != nil {
+		return nil, "", err
+	}
+	return f, contentTypes[ext], nil
+}
+
+// Delete removes every size of the avatar at avatarURL. URLs that Save did
+// not issue, such as an empty one, are ignored.
+func (s *Store) Delete(ctx context.Context, avatarURL string) error {
+	file, ok := strings.CutPrefix(avatarURL, URLPrefix)
+	if !ok {
+		return nil
+	}
+	id, ext, ok := parseFile(file)
+	if !ok {
+		return nil
+	}
+
+	var errs []error
+	for _, size := range Sizes {
+		err := s.storage.Delete(ctx, fileName(id, size, ext))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// URL returns the image source for a size×size avatar: avatarURL when the
+// user uploaded one, and otherwise their identicon, served by the
+// application under /identicons/.
+func URL(username, avatarURL string, size int) string {
+	query := "?s=" + strconv.Itoa(Fit(size))
+	if avatarURL == "" {
+		return "/identicons/" + url.PathEscape(username) + ".png" + query
+	}
+	return avatarURL + query
+}
+
+func fileName(id string, size int, ext string) string {
+	return id + "-" + strconv.Itoa(size) + "." + ext
+}
+
+// parseFile splits "<id>.<ext>" as built by Save, checking both parts.
+func parseFile(file string) (id, ext string, ok bool) {
+	id, ext, ok = strings.Cut(file, ".")
+	if !ok || id == "" || contentTypes[ext] == "" {
+		return "", "", false
+	}
+	for _, c := range id {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') && c != '-' {
+			return "", "", false
+		}
+	}
+	return id, ext, true
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/dunamismax/go-stdlib/apps/web/go-social/avatar"
+	"github.com/dunamismax/go-stdlib/pkg/database"
+	"github.com/dunamismax/go-stdlib/pkg/middleware"
+	"github.com/dunamismax/go-stdlib/pkg/server"
+	"github.com/dunamismax/go-stdlib/pkg/tracing"
+	"github.com/dunamismax/go-stdlib/pkg/utils"
+)
+
+// Config is loaded by config.Load from defaultConfig, an optional file, the
+// environment and flags. Run with -h to list every setting.
+type Config struct {
+	Env      string     `config:"app_env" usage:"deployment environment; production refuses the default session secret"`
+	LogLevel slog.Level `config:"log_level" usage:"minimum log level (DEBUG, INFO, WARN, ERROR)"`
+	DataDir  string     `config:"data_dir" usage:"directory holding the SQLite database"`
+	HTTPS    bool       `config:"https" usage:"mark cookies Secure and send HSTS"`
+
+	// SessionKeys rotates signing keys as "id:secret" entries, newest first,
+	// separated by commas or newlines; SESSION_KEYS_FILE reads them from a
+	// file. It takes precedence over SessionSecret.
+	SessionKeys        string        `config:"session_keys" secret:"true" usage:"session signing keys as id:secret, newest first"`
+	SessionSecret      string        `config:"session_secret" secret:"true" usage:"single session signing secret"`
+	SessionIdleTimeout time.Duration `config:"session_idle_timeout" usage:"sign out after this long without activity"`
+	SessionMaxLifetime time.Duration `config:"session_max_lifetime" usage:"sign out this long after login regardless of activity"`
+	SessionCleanup     time.Duration `config:"session_cleanup_interval" usage:"how often expired sessions are deleted"`
+
+	TrustedProxies     []string `config:"trusted_proxies" usage:"proxy IPs or CIDRs whose forwarding headers are trusted"`
+	CORSAllowedOrigins []string `config:"cors_allowed_origins" usage:"origins that may call /api/ with credentials"`
+
+	Avatar   avatar.Config         `config:"avatar"`
+	Database database.Config       `config:"db"`
+	Backup   database.BackupConfig `config:"backup"`
+	Server   server.Config         `config:"server"`
+	Tracing  tracing.Config        `config:"tracing"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		Env:                "development",
+		LogLevel:           slog.LevelInfo,
+		DataDir:            "./data",
+		SessionIdleTimeout: 7 * 24 * time.Hour,
+		SessionMaxLifetime: 30 * 24 * time.Hour,
+		SessionCleanup:     time.Hour,
+		Avatar:             avatar.Config{}.WithDefaults(),
+		Server:             server.Config{Addr: ":8081"}.WithDefaults(),
+		Tracing:            tracing.Config{ServiceName: "go-social"},
+	}
+}
+
+// Validate implements config.Validator.
+func (c *Config) Validate() error {
+	if c.SessionIdleTimeout <= 0 || c.SessionMaxLifetime < c.SessionIdleTimeout {
+		return errors.New("session_max_lifetime must be at least session_idle_timeout, and both positive")
+	}
+	if c.SessionCleanup <= 0 {
+		return errors.New("session_cleanup_interval must be positive")
+	}
+	if c.Avatar.MaxBytes <= 0 || c.Avatar.MaxPixels <= 0 {
+		return errors.New("avatar.max_bytes and avatar.max_pixels must be positive")
+	}
+	// /api/ is called with credentials, so the origins must be specific
+	for _, origin := range c.CORSAllowedOrigins {
+		if err := middleware.CheckCredentialedOrigin(origin); err != nil {
+			return fmt.Errorf("invalid cors_allowed_origins: %w", err)
+		}
+	}
+	return nil
+}
+
+// sessionKeyring builds the signing keyring from SessionKeys or a single
+// SessionSecret, falling back to the development default.
+func (c *Config) sessionKeyring() (*utils.Keyring, error) {
+	if c.SessionKeys != "" {
+		return utils.ParseKeyring(c.SessionKeys)
+	}
+
+	secret := c.SessionSecret
+	if secret == "" {
+		secret = utils.DefaultSessionSecret
+	}
+	return utils.NewKeyring(utils.SigningKey{Secret: secret})
+}
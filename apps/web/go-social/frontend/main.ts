@@ -51,7 +51,8 @@ class SocialApp {
     fetch(`/like/${postId}`, {
       method: 'POST',
       headers: {
-        'Content-Type': 'application/json'
+        'Content-Type': 'application/json',
+        'X-CSRF-Token': this.csrfToken()
       }
     })
     .then(response => response.json())
@@ -80,6 +81,9 @@ class SocialApp {
     const form = document.querySelector('#post-form') as HTMLFormElement
     if (!form) return
 
+    // Clear the form once htmx has posted it (hx-on would need 'unsafe-eval')
+    form.addEventListener('htmx:afterRequest', () => form.reset())
+
     form.addEventListener('submit', (e) => {
       e.preventDefault()
       
@@ -115,6 +119,11 @@ class SocialApp {
     })
   }
 
+  private csrfToken(): string {
+    const meta = document.querySelector('meta[name="csrf-token"]') as HTMLMetaElement | null
+    return meta?.content ?? ''
+  }
+
   private showMessage(message: string, type: 'success' | 'error'): void {
     const messageEl = document.createElement('div')
     messageEl.className = `message message-${type}`
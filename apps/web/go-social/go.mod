@@ -5,8 +5,16 @@ go 1.24
 toolchain go1.24.5
 
 require (
+	github.com/dunamismax/go-stdlib/pkg/auth v0.0.0
+	github.com/dunamismax/go-stdlib/pkg/config v0.0.0
 	github.com/dunamismax/go-stdlib/pkg/database v0.0.0
+	github.com/dunamismax/go-stdlib/pkg/health v0.0.0
+	github.com/dunamismax/go-stdlib/pkg/metrics v0.0.0
+	github.com/dunamismax/go-stdlib/pkg/middleware v0.0.0
+	github.com/dunamismax/go-stdlib/pkg/server v0.0.0
+	github.com/dunamismax/go-stdlib/pkg/tracing v0.0.0
 	github.com/dunamismax/go-stdlib/pkg/utils v0.0.0
+	golang.org/x/image v0.25.0
 )
 
 require (
@@ -16,7 +24,8 @@ require (
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/ncruces/go-strftime v0.1.9 // indirect
 	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
-	golang.org/x/sys v0.22.0 // indirect
+	golang.org/x/crypto v0.40.0 // indirect
+	golang.org/x/sys v0.34.0 // indirect
 	modernc.org/gc/v3 v3.0.0-20240107210532-573471604cb6 // indirect
 	modernc.org/libc v1.55.3 // indirect
 	modernc.org/mathutil v1.6.0 // indirect
@@ -26,6 +35,20 @@ require (
 	modernc.org/token v1.1.0 // indirect
 )
 
+replace github.com/dunamismax/go-stdlib/pkg/auth => ../../../pkg/auth
+
+replace github.com/dunamismax/go-stdlib/pkg/config => ../../../pkg/config
+
 replace github.com/dunamismax/go-stdlib/pkg/database => ../../../pkg/database
 
+replace github.com/dunamismax/go-stdlib/pkg/health => ../../../pkg/health
+
+replace github.com/dunamismax/go-stdlib/pkg/metrics => ../../../pkg/metrics
+
+replace github.com/dunamismax/go-stdlib/pkg/middleware => ../../../pkg/middleware
+
+replace github.com/dunamismax/go-stdlib/pkg/server => ../../../pkg/server
+
+replace github.com/dunamismax/go-stdlib/pkg/tracing => ../../../pkg/tracing
+
 replace github.com/dunamismax/go-stdlib/pkg/utils => ../../../pkg/utils
@@ -1,10 +1,14 @@
 package handlers
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
-	"os"
 
 	"github.com/dunamismax/go-stdlib/apps/web/go-social/models"
+	"github.com/dunamismax/go-stdlib/pkg/auth"
+	"github.com/dunamismax/go-stdlib/pkg/database"
+	"github.com/dunamismax/go-stdlib/pkg/middleware"
 	"github.com/dunamismax/go-stdlib/pkg/utils"
 )
 
@@ -12,9 +16,12 @@ func (h *Handler) LoginPageHandler(w http.ResponseWriter, r *http.Request) {
 	data := PageData{
 		Title:      "Login - GoSocial",
 		IsLoggedIn: false,
+		CSRFToken:  middleware.CSRFToken(r),
+		CSRFField:  middleware.CSRFField(r),
+		CSPNonce:   middleware.CSPNonce(r),
 	}
 
-	if err := h.templates.ExecuteTemplate(w, "login.html", data); err != nil {
+	if err := h.templates.ExecuteTemplate(r.Context(), w, "login.html", data); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
@@ -38,23 +45,40 @@ func (h *Handler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.userService.AuthenticateUser(username, password)
-	if err != nil {
+	user, err := h.userService.AuthenticateUser(r.Context(), username, password)
+	if errors.Is(err, models.ErrInvalidCredentials) {
 		http.Redirect(w, r, "/login?error=invalid_credentials", http.StatusSeeOther)
 		return
 	}
+	if err != nil {
+		slog.Error("Failed to authenticate user", "error", err)
+		http.Error(w, "Failed to sign in", http.StatusInternalServerError)
+		return
+	}
 
-	h.setSession(w, user.ID)
+	if err := h.setSession(w, r, user.ID); err != nil {
+		http.Error(w, "Failed to sign in", http.StatusInternalServerError)
+		return
+	}
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
 func (h *Handler) RegisterPageHandler(w http.ResponseWriter, r *http.Request) {
+	h.renderRegister(w, r, "")
+}
+
+// renderRegister shows the registration form with an optional error.
+func (h *Handler) renderRegister(w http.ResponseWriter, r *http.Request, message string) {
 	data := PageData{
 		Title:      "Register - GoSocial",
 		IsLoggedIn: false,
+		CSRFToken:  middleware.CSRFToken(r),
+		CSRFField:  middleware.CSRFField(r),
+		CSPNonce:   middleware.CSPNonce(r),
+		Error:      message,
 	}
 
-	if err := h.templates.ExecuteTemplate(w, "register.html", data); err != nil {
+	if err := h.templates.ExecuteTemplate(r.Context(), w, "register.html", data); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
@@ -76,57 +100,161 @@ func (h *Handler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.userService.CreateUser(username, email, password, displayName)
+	user, err := h.userService.CreateUser(r.Context(), username, email, password, displayName)
+	if errors.Is(err, database.ErrConflict) {
+		w.WriteHeader(http.StatusConflict)
+		h.renderRegister(w, r, "That username or email is already registered.")
+		return
+	}
 	if err != nil {
+		slog.Error("Failed to create user", "error", err)
 		http.Redirect(w, r, "/register?error=creation_failed", http.StatusSeeOther)
 		return
 	}
 
-	h.setSession(w, user.ID)
+	if err := h.setSession(w, r, user.ID); err != nil {
+		http.Error(w, "Account created, but signing in failed. Please log in.", http.StatusInternalServerError)
+		return
+	}
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
 func (h *Handler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	if token := h.sessionToken(r); token != nil {
+		h.sessions.Revoke(r.Context(), token.SessionID)
+	}
 	h.clearSession(w)
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
-func (h *Handler) getSessionSecret() string {
-	secret := os.Getenv("SESSION_SECRET")
-	if secret == "" {
-		secret = "default-secret-key-change-in-production"
+// LogoutAllHandler revokes every session of the current user, signing them
+// out on all devices.
+func (h *Handler) LogoutAllHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := h.getCurrentUser(r)
+	if currentUser != nil {
+		if err := h.sessions.RevokeAll(r.Context(), currentUser.ID); err != nil {
+			slog.Error("Failed to revoke sessions", "user_id", currentUser.ID, "error", err)
+		}
+	}
+	h.clearSession(w)
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+type sessionInfo struct {
+	*auth.Session
+	Current bool `json:"current"`
+}
+
+// GetSessionsHandler lists the current user's active sessions.
+func (h *Handler) GetSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	token := h.sessionToken(r)
+	currentUser := h.getCurrentUser(r)
+	if token == nil || currentUser == nil {
+		utils.Error(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	sessions, err := h.sessions.List(r.Context(), currentUser.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to load sessions")
+		return
+	}
+
+	result := make([]sessionInfo, 0, len(sessions))
+	for _, s := range sessions {
+		result = append(result, sessionInfo{Session: s, Current: s.ID == token.SessionID})
 	}
-	return secret
+
+	utils.Success(w, result)
 }
 
-func (h *Handler) setSession(w http.ResponseWriter, userID int) {
-	secretKey := h.getSessionSecret()
-	token, err := utils.CreateSessionToken(userID, secretKey, 168) // 7 days
+// RevokeSessionHandler ends one of the current user's sessions by ID.
+func (h *Handler) RevokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := h.getCurrentUser(r)
+	if currentUser == nil {
+		utils.Error(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	sessions, err := h.sessions.List(r.Context(), currentUser.ID)
 	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to load sessions")
 		return
 	}
 
-	isSecure := os.Getenv("HTTPS") == "true"
-	utils.SetSecureCookie(w, "session", token, 86400*7, isSecure)
+	sessionID := r.PathValue("id")
+	for _, s := range sessions {
+		if s.ID == sessionID {
+			if err := h.sessions.Revoke(r.Context(), sessionID); err != nil {
+				utils.Error(w, http.StatusInternalServerError, "Failed to revoke session")
+				return
+			}
+			utils.Success(w, map[string]string{"revoked": sessionID})
+			return
+		}
+	}
+
+	utils.Error(w, http.StatusNotFound, "Session not found")
+}
+
+// setSession starts a session for userID and sets its cookie. Failures are
+// logged; a session whose token cannot be signed is revoked again.
+func (h *Handler) setSession(w http.ResponseWriter, r *http.Request, userID int) error {
+	session, err := h.sessions.Create(r.Context(), userID, r.UserAgent(), middleware.ClientIP(r))
+	if err != nil {
+		slog.Error("Failed to create session", "user_id", userID, "error", err)
+		return err
+	}
+
+	expiresAt := session.CreatedAt.Add(h.sessions.MaxLifetime())
+	token, err := h.keyring.SignSessionToken(utils.SessionToken{
+		UserID:    userID,
+		SessionID: session.ID,
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		slog.Error("Failed to sign session token", "user_id", userID, "error", err)
+		if err := h.sessions.Revoke(r.Context(), session.ID); err != nil {
+			slog.Error("Failed to revoke unusable session", "user_id", userID, "error", err)
+		}
+		return err
+	}
+
+	utils.SetSecureCookie(w, "session", token, int(h.sessions.MaxLifetime().Seconds()), h.secureCookies)
+	return nil
 }
 
 func (h *Handler) clearSession(w http.ResponseWriter) {
 	utils.ClearCookie(w, "session")
 }
 
-func (h *Handler) getCurrentUser(r *http.Request) *models.User {
+// sessionToken returns the verified contents of the session cookie, or nil.
+func (h *Handler) sessionToken(r *http.Request) *utils.SessionToken {
 	cookie, err := r.Cookie("session")
 	if err != nil {
 		return nil
 	}
 
-	secretKey := h.getSessionSecret()
-	sessionToken, err := utils.ValidateSessionToken(cookie.Value, secretKey)
-	if err != nil {
+	token, err := h.keyring.ValidateSessionToken(cookie.Value)
+	if err != nil || token.SessionID == "" {
+		return nil
+	}
+
+	return token
+}
+
+func (h *Handler) getCurrentUser(r *http.Request) *models.User {
+	token := h.sessionToken(r)
+	if token == nil {
+		return nil
+	}
+
+	session, err := h.sessions.Validate(r.Context(), token.SessionID)
+	if err != nil || session.UserID != token.UserID {
 		return nil
 	}
 
-	user, err := h.userService.GetUserByID(sessionToken.UserID)
+	user, err := h.userService.GetUserByID(r.Context(), session.UserID)
 	if err != nil {
 		return nil
 	}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image/png"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dunamismax/go-stdlib/apps/web/go-social/avatar"
+	"github.com/dunamismax/go-stdlib/apps/web/go-social/models"
+)
+
+// UploadAvatarHandler replaces the current user's avatar with the picture
+// in the "avatar" field of a multipart form.
+func (h *Handler) UploadAvatarHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := h.getCurrentUser(r)
+	if currentUser == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	file, _, err := r.FormFile("avatar")
+	if err != nil {
+		h.avatarError(w, r, currentUser, err)
+		return
+	}
+	defer file.Close()
+
+	ctx := r.Context()
+	avatarURL, err := h.avatars.Save(ctx, currentUser.ID, file)
+	if err != nil {
+		h.avatarError(w, r, currentUser, err)
+		return
+	}
+	if err := h.userService.UpdateAvatar(ctx, currentUser.ID, avatarURL); err != nil {
+		if err := h.avatars.Delete(ctx, avatarURL); err != nil {
+			slog.Warn("Failed to delete unused avatar", "url", avatarURL, "error", err)
+		}
+		h.avatarError(w, r, currentUser, err)
+		return
+	}
+
+	h.deleteAvatar(r, currentUser, avatarURL)
+	http.Redirect(w, r, "/settings", http.StatusSeeOther)
+}
+
+// RemoveAvatarHandler goes back to the current user's identicon.
+func (h *Handler) RemoveAvatarHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := h.getCurrentUser(r)
+	if currentUser == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	if err := h.userService.UpdateAvatar(r.Context(), currentUser.ID, ""); err != nil {
+		h.avatarError(w, r, currentUser, err)
+		return
+	}
+
+	h.deleteAvatar(r, currentUser, "")
+	http.Redirect(w, r, "/settings", http.StatusSeeOther)
+}
+
+// deleteAvatar removes the files of the avatar user had before it was
+// replaced by current. Failing only leaves unused files, so it just logs.
+func (h *Handler) deleteAvatar(r *http.Request, user *models.User, current string) {
+	if user.AvatarURL == current {
+		return
+	}
+	if err := h.avatars.Delete(r.Context(), user.AvatarURL); err != nil {
+		slog.Warn("Failed to delete old avatar", "user_id", user.ID, "url", user.AvatarURL, "error", err)
+	}
+}
+
+// avatarError redisplays the settings page explaining why an upload
+// failed.
+func (h *Handler) avatarError(w http.ResponseWriter, r *http.Request, currentUser *models.User, err error) {
+	status := errorStatus(err)
+	message := "Failed to save your avatar. Please try again."
+	switch {
+	case errors.Is(err, http.ErrMissingFile):
+		status, message = http.StatusBadRequest, "Choose a picture to upload."
+	case errors.Is(err, avatar.ErrUnsupportedFormat):
+		message = "Upload a PNG, JPEG, GIF or WebP picture."
+	case errors.Is(err, avatar.ErrInvalidImage):
+		message = "That picture could not be read."
+	case status == http.StatusRequestEntityTooLarge:
+		message = "That picture is too large."
+	case status == http.StatusInternalServerError:
+		slog.Error("Failed to save avatar", "user_id", currentUser.ID, "error", err)
+	}
+
+	w.WriteHeader(status)
+	h.renderSettings(w, r, currentUser, &models.Profile{User: *currentUser}, message)
+}
+
+// avatarSize reads the ?s= of avatar and identicon URLs as one of
+// avatar.Sizes.
+func avatarSize(r *http.Request) int {
+	size, err := strconv.Atoi(r.URL.Query().Get("s"))
+	if err != nil || size <= 0 {
+		return avatar.Sizes[0]
+	}
+	return avatar.Fit(size)
+}
+
+// ServeAvatarHandler serves an uploaded avatar at the size in ?s=. Avatar
+// URLs change whenever the picture does, so responses may be cached for
+// good.
+func (h *Handler) ServeAvatarHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("file")
+	size := avatarSize(r)
+	f, contentType, err := h.avatars.Open(r.Context(), name, size)
+	if errors.Is(err, fs.ErrNotExist) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to open avatar", "file", name, "error", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("ETag", fmt.Sprintf(`"%s-%d"`, name, size))
+	http.ServeContent(w, r, "", time.Time{}, f)
+}
+
+// IdenticonHandler draws the default avatar for the username in
+// /identicons/{username}.png at the size in ?s=. It depends on nothing but
+// the username, so any name gets one and no lookup is needed.
+func (h *Handler) IdenticonHandler(w http.ResponseWriter, r *http.Request) {
+	username, ok := strings.CutSuffix(r.PathValue("file"), ".png")
+	if !ok || username == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, avatar.Identicon(username, avatarSize(r))); err != nil {
+		slog.Error("Failed to encode identicon", "error", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age=604800")
+	w.Header().Set("ETag", fmt.Sprintf(`"%08x"`, crc32.ChecksumIEEE(buf.Bytes())))
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(buf.Bytes()))
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dunamismax/go-stdlib/apps/web/go-social/avatar"
+	"github.com/dunamismax/go-stdlib/apps/web/go-social/models"
+	"github.com/dunamismax/go-stdlib/pkg/database"
+)
+
+// errorStatus maps an error from the service layer to the HTTP status it
+// stands for; anything unrecognised is a server error.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, database.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, database.ErrConstraint), errors.Is(err, database.ErrInvalidCursor),
+		errors.Is(err, models.ErrSelfFollow), errors.Is(err, avatar.ErrInvalidImage):
+		return http.StatusBadRequest
+	case errors.Is(err, avatar.ErrTooLarge), errors.As(err, new(*http.MaxBytesError)):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, avatar.ErrUnsupportedFormat):
+		return http.StatusUnsupportedMediaType
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/dunamismax/go-stdlib/apps/web/go-social/models"
+	"github.com/dunamismax/go-stdlib/pkg/database"
+	"github.com/dunamismax/go-stdlib/pkg/middleware"
+	"github.com/dunamismax/go-stdlib/pkg/utils"
+)
+
+// followListLimit caps the followers and following pages.
+const followListLimit = 100
+
+// followButton is the data of the "follow-button" partial. postView and
+// connectionView carry the same fields, so they render it directly.
+type followButton struct {
+	Username    string
+	IsFollowing bool
+}
+
+// connectionView is a row of the followers and following pages.
+type connectionView struct {
+	models.Connection
+	// CanFollow is set when the viewer is signed in and not this user
+	CanFollow bool
+}
+
+func (h *Handler) FollowHandler(w http.ResponseWriter, r *http.Request) {
+	h.setFollowing(w, r, true)
+}
+
+func (h *Handler) UnfollowHandler(w http.ResponseWriter, r *http.Request) {
+	h.setFollowing(w, r, false)
+}
+
+// setFollowing answers HTMX with the swapped follow button and other
+// clients with the new state and the followed user's counts as JSON.
+func (h *Handler) setFollowing(w http.ResponseWriter, r *http.Request, follow bool) {
+	currentUser := h.getCurrentUser(r)
+	if currentUser == nil {
+		if isHTMXRequest(r) {
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<button class="follow-btn">Must login</button>`)
+			return
+		}
+		utils.Error(w, http.StatusUnauthorized, "Must be logged in")
+		return
+	}
+
+	username := r.PathValue("username")
+	var counts models.FollowCounts
+	var err error
+	if follow {
+		counts, err = h.userService.Follow(r.Context(), currentUser.ID, username)
+	} else {
+		counts, err = h.userService.Unfollow(r.Context(), currentUser.ID, username)
+	}
+	if err != nil {
+		status := errorStatus(err)
+		if status == http.StatusInternalServerError {
+			slog.Error("Failed to update follow", "username", username, "error", err)
+		}
+		if isHTMXRequest(r) {
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(status)
+			fmt.Fprintf(w, `<button class="follow-btn">%s</button>`, http.StatusText(status))
+			return
+		}
+		switch {
+		case errors.Is(err, database.ErrNotFound):
+			utils.Error(w, status, "User not found")
+		case errors.Is(err, models.ErrSelfFollow):
+			utils.Error(w, status, "You cannot follow yourself")
+		default:
+			utils.Error(w, status, "Failed to update follow")
+		}
+		return
+	}
+
+	if isHTMXRequest(r) {
+		w.Header().Set("Content-Type", "text/html")
+		button := followButton{Username: username, IsFollowing: follow}
+		if err := h.templates.ExecuteTemplate(r.Context(), w, "follow-button", button); err != nil {
+			slog.Error("Failed to render follow button", "error", err)
+			http.Error(w, "Failed to render follow button", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	utils.Success(w, map[string]interface{}{
+		"following": follow,
+		"counts":    counts,
+	})
+}
+
+func (h *Handler) FollowersHandler(w http.ResponseWriter, r *http.Request) {
+	h.renderFollowList(w, r, "followers")
+}
+
+func (h *Handler) FollowingHandler(w http.ResponseWriter, r *http.Request) {
+	h.renderFollowList(w, r, "following")
+}
+
+// renderFollowList shows who follows, or is followed by, the user named in
+// the path.
+func (h *Handler) renderFollowList(w http.ResponseWriter, r *http.Request, list string) {
+	ctx := r.Context()
+	currentUser := h.getCurrentUser(r)
+	viewerID := 0
+	if currentUser != nil {
+		viewerID = currentUser.ID
+	}
+
+	profile, err := h.userService.GetProfile(ctx, r.PathValue("username"), viewerID)
+	if err != nil {
+		status := errorStatus(err)
+		if status == http.StatusInternalServerError {
+			slog.Error("Failed to load user", "error", err)
+		}
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	var connections []models.Connection
+	if list == "followers" {
+		connections, err = h.userService.GetFollowers(ctx, profile.ID, viewerID, followListLimit)
+	} else {
+		connections, err = h.userService.GetFollowing(ctx, profile.ID, viewerID, followListLimit)
+	}
+	if err != nil {
+		slog.Error("Failed to load follow list", "list", list, "user_id", profile.ID, "error", err)
+		http.Error(w, "Failed to load users", http.StatusInternalServerError)
+		return
+	}
+
+	data := PageData{
+		Title:     fmt.Sprintf("@%s %s - GoSocial", profile.Username, list),
+		User:      currentUser,
+		CSRFToken: middleware.CSRFToken(r),
+		CSRFField: middleware.CSRFField(r),
+		CSPNonce:  middleware.CSPNonce(r),
+		Profile:   profile,
+		List:      list,
+	}
+	if currentUser != nil {
+		data.IsLoggedIn = true
+		data.Username = currentUser.Username
+	}
+	for _, c := range connections {
+		data.Connections = append(data.Connections, connectionView{
+			Connection: c,
+			CanFollow:  currentUser != nil && currentUser.ID != c.ID,
+		})
+	}
+
+	if err := h.templates.ExecuteTemplate(ctx, w, "follows.html", data); err != nil {
+		slog.Error("Failed to render follow list", "error", err)
+		http.Error(w, "Failed to render page", http.StatusInternalServerError)
+	}
+}
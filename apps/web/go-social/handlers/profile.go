@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dunamismax/go-stdlib/apps/web/go-social/models"
+	"github.com/dunamismax/go-stdlib/pkg/middleware"
+	"github.com/dunamismax/go-stdlib/pkg/utils"
+)
+
+// ProfileHandler shows a user's profile: their bio, follow counts and
+// posts. Like HomeHandler, HTMX requests with a ?cursor= get just the next
+// page of posts.
+func (h *Handler) ProfileHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	currentUser := h.getCurrentUser(r)
+	viewerID := 0
+	if currentUser != nil {
+		viewerID = currentUser.ID
+	}
+
+	profile, err := h.userService.GetProfile(ctx, r.PathValue("username"), viewerID)
+	if err != nil {
+		status := errorStatus(err)
+		if status == http.StatusInternalServerError {
+			slog.Error("Failed to load profile", "error", err)
+		}
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	base := "/u/" + url.PathEscape(profile.Username)
+	cursor := r.URL.Query().Get("cursor")
+	page, err := h.userService.GetUserPosts(ctx, profile.ID, viewerID, cursor, pageSize)
+	if isHTMXRequest(r) && cursor != "" {
+		h.renderPostPage(w, r, page, err, base, false)
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to load posts", "user_id", profile.ID, "error", err)
+	}
+
+	data := PageData{
+		Title:     fmt.Sprintf("@%s - GoSocial", profile.Username),
+		User:      currentUser,
+		CSRFToken: middleware.CSRFToken(r),
+		CSRFField: middleware.CSRFField(r),
+		CSPNonce:  middleware.CSPNonce(r),
+		Profile:   profile,
+		CanFollow: currentUser != nil && currentUser.ID != profile.ID,
+		Posts:     postViews(page.Posts, currentUser, false),
+		NextURL:   nextPageURL(base, page.NextCursor),
+	}
+	if currentUser != nil {
+		data.IsLoggedIn = true
+		data.Username = currentUser.Username
+	}
+
+	if err := h.templates.ExecuteTemplate(ctx, w, "profile.html", data); err != nil {
+		slog.Error("Failed to render profile page", "error", err)
+		http.Error(w, "Failed to render page", http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) SettingsPageHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := h.getCurrentUser(r)
+	if currentUser == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+	h.renderSettings(w, r, currentUser, &models.Profile{User: *currentUser}, "")
+}
+
+// SettingsHandler saves the current user's display name and bio and sends
+// them to their profile. Invalid values redisplay the form with a 400.
+func (h *Handler) SettingsHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser := h.getCurrentUser(r)
+	if currentUser == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	submitted := &models.Profile{User: *currentUser}
+	submitted.DisplayName = utils.SanitizeInput(r.FormValue("display_name"))
+	submitted.Bio = utils.SanitizeInput(r.FormValue("bio"))
+
+	var messages []string
+	if err := utils.ValidateDisplayName(submitted.DisplayName); err != nil {
+		messages = append(messages, err.Message)
+	}
+	if err := utils.ValidateBio(submitted.Bio); err != nil {
+		messages = append(messages, err.Message)
+	}
+	if len(messages) > 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		h.renderSettings(w, r, currentUser, submitted, strings.Join(messages, ". "))
+		return
+	}
+
+	user, err := h.userService.UpdateProfile(r.Context(), currentUser.ID, submitted.DisplayName, submitted.Bio)
+	if err != nil {
+		slog.Error("Failed to update profile", "user_id", currentUser.ID, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		h.renderSettings(w, r, currentUser, submitted, "Failed to save your profile. Please try again.")
+		return
+	}
+
+	http.Redirect(w, r, "/u/"+url.PathEscape(user.Username), http.StatusSeeOther)
+}
+
+// renderSettings shows the settings form filled in from form, with an
+// optional error.
+func (h *Handler) renderSettings(w http.ResponseWriter, r *http.Request, currentUser *models.User, form *models.Profile, message string) {
+	data := PageData{
+		Title:      "Settings - GoSocial",
+		IsLoggedIn: true,
+		Username:   currentUser.Username,
+		User:       currentUser,
+		CSRFToken:  middleware.CSRFToken(r),
+		CSRFField:  middleware.CSRFField(r),
+		CSPNonce:   middleware.CSPNonce(r),
+		Profile:    form,
+		Error:      message,
+	}
+
+	if err := h.templates.ExecuteTemplate(r.Context(), w, "settings.html", data); err != nil {
+		slog.Error("Failed to render settings page", "error", err)
+		http.Error(w, "Failed to render page", http.StatusInternalServerError)
+	}
+}
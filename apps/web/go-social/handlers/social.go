@@ -4,30 +4,84 @@ import (
 	_ "embed"
 	"fmt"
 	"html/template"
+	"log/slog"
 	"net/http"
 	"strconv"
 
+	"github.com/dunamismax/go-stdlib/apps/web/go-social/avatar"
 	"github.com/dunamismax/go-stdlib/apps/web/go-social/models"
+	"github.com/dunamismax/go-stdlib/pkg/auth"
+	"github.com/dunamismax/go-stdlib/pkg/middleware"
 	"github.com/dunamismax/go-stdlib/pkg/utils"
 )
 
 type Handler struct {
 	userService *models.UserService
-	templates   *template.Template
+	sessions    *auth.SessionManager
+	keyring     *utils.Keyring
+	templates   *Templates
+	avatars     *avatar.Store
+	// secureCookies marks cookies Secure when the site is served over HTTPS
+	secureCookies bool
 }
 
 type PageData struct {
 	Title      string
 	IsLoggedIn bool
 	Username   string
-	Posts      []*models.Post
+	Posts      []postView
 	User       *models.User
+	CSRFToken  string
+	CSRFField  template.HTML
+	CSPNonce   string
+	Error      string
+	// Feed is the home tab being shown: "following" or "everyone"
+	Feed string
+	// Counts are the follow counts of User on the home page
+	Counts models.FollowCounts
+	// Profile is the user whose profile, followers or following page this
+	// is, or the submitted values on the settings page
+	Profile *models.Profile
+	// CanFollow is set when the viewer is signed in and not Profile
+	CanFollow bool
+	// List and Connections fill the followers and following pages
+	List        string
+	Connections []connectionView
+	// NextURL loads the page after Posts; empty on the last page
+	NextURL string
 }
 
-func NewHandler(userService *models.UserService, templates *template.Template) *Handler {
+// postView is what the "post" partial renders: the post plus what the
+// viewer may do with it.
+type postView struct {
+	*models.Post
+	IsLoggedIn bool
+	// CanFollow is set when the viewer is signed in and not the author
+	CanFollow bool
+}
+
+// postViews prepares posts for viewer. follow offers to follow each post's
+// author; profile pages turn it off as their header has the button.
+func postViews(posts []*models.Post, viewer *models.User, follow bool) []postView {
+	views := make([]postView, 0, len(posts))
+	for _, post := range posts {
+		views = append(views, postView{
+			Post:       post,
+			IsLoggedIn: viewer != nil,
+			CanFollow:  follow && viewer != nil && viewer.ID != post.UserID,
+		})
+	}
+	return views
+}
+
+func NewHandler(userService *models.UserService, sessions *auth.SessionManager, keyring *utils.Keyring, templates *Templates, avatars *avatar.Store, secureCookies bool) *Handler {
 	return &Handler{
-		userService: userService,
-		templates:   templates,
+		userService:   userService,
+		sessions:      sessions,
+		keyring:       keyring,
+		templates:     templates,
+		avatars:       avatars,
+		secureCookies: secureCookies,
 	}
 }
 
@@ -40,34 +94,48 @@ func isHTMXRequest(r *http.Request) bool {
 	return r.Header.Get("HX-Request") == "true"
 }
 
+// HomeHandler shows the Following timeline to signed-in users and the
+// Everyone feed to visitors or when ?feed=everyone is asked for. HTMX
+// requests with a ?cursor= get just the next page of posts, for infinite
+// scroll.
 func (h *Handler) HomeHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	currentUser := h.getCurrentUser(r)
-	userID := 0
-	isLoggedIn := false
 
-	if currentUser != nil {
-		userID = currentUser.ID
-		isLoggedIn = true
+	data := PageData{
+		Title:     "GoSocial",
+		Feed:      timelineFeed(r, currentUser),
+		User:      currentUser,
+		CSRFToken: middleware.CSRFToken(r),
+		CSRFField: middleware.CSRFField(r),
+		CSPNonce:  middleware.CSPNonce(r),
 	}
 
-	posts, err := h.userService.GetRecentPosts(userID, 20)
-	if err != nil {
-		posts = []*models.Post{}
+	cursor := r.URL.Query().Get("cursor")
+	page, err := h.timelinePage(ctx, data.Feed, currentUser, cursor, pageSize)
+	if isHTMXRequest(r) && cursor != "" {
+		h.renderPostPage(w, r, page, err, "/?feed="+data.Feed, true)
+		return
 	}
-
-	data := PageData{
-		Title:      "GoSocial",
-		IsLoggedIn: isLoggedIn,
-		Posts:      posts,
-		User:       currentUser,
+	if err != nil {
+		slog.Error("Failed to load posts", "feed", data.Feed, "error", err)
 	}
+	data.Posts = postViews(page.Posts, currentUser, true)
+	data.NextURL = nextPageURL("/?feed="+data.Feed, page.NextCursor)
 
 	if currentUser != nil {
+		data.IsLoggedIn = true
 		data.Username = currentUser.Username
+
+		data.Counts, err = h.userService.GetFollowCounts(ctx, currentUser.ID)
+		if err != nil {
+			slog.Error("Failed to load follow counts", "user_id", currentUser.ID, "error", err)
+		}
 	}
 
-	if err := h.templates.ExecuteTemplate(w, "home.html", data); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.templates.ExecuteTemplate(ctx, w, "home.html", data); err != nil {
+		slog.Error("Failed to render home page", "error", err)
+		http.Error(w, "Failed to render page", http.StatusInternalServerError)
 	}
 }
 
@@ -105,7 +173,7 @@ func (h *Handler) CreatePostHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	post, err := h.userService.CreatePost(currentUser.ID, content)
+	post, err := h.userService.CreatePost(r.Context(), currentUser.ID, content)
 	if err != nil {
 		if isHTMXRequest(r) {
 			w.Header().Set("Content-Type", "text/html")
@@ -121,24 +189,20 @@ func (h *Handler) CreatePostHandler(w http.ResponseWriter, r *http.Request) {
 
 		// Create a new post with the current user data
 		newPost := &models.Post{
-			ID:        post.ID,
-			Username:  currentUser.Username,
-			Content:   post.Content,
-			CreatedAt: post.CreatedAt,
-			LikeCount: 0,
-			IsLiked:   false,
+			ID:          post.ID,
+			Username:    currentUser.Username,
+			DisplayName: currentUser.DisplayName,
+			AvatarURL:   currentUser.AvatarURL,
+			Content:     post.Content,
+			CreatedAt:   post.CreatedAt,
+			LikeCount:   0,
+			IsLiked:     false,
 		}
 
 		// Render just the post template
-		postData := struct {
-			*models.Post
-			IsLoggedIn bool
-		}{
-			Post:       newPost,
-			IsLoggedIn: true,
-		}
+		postData := postView{Post: newPost, IsLoggedIn: true}
 
-		if err := h.templates.ExecuteTemplate(w, "post", postData); err != nil {
+		if err := h.templates.ExecuteTemplate(r.Context(), w, "post", postData); err != nil {
 			fmt.Fprint(w, `<div class="error">Failed to render post</div>`)
 		}
 		return
@@ -171,37 +235,23 @@ func (h *Handler) LikePostHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if already liked
-	post, err := h.userService.GetPostByID(postID, currentUser.ID)
+	liked, likes, err := h.userService.ToggleLike(r.Context(), currentUser.ID, postID)
 	if err != nil {
+		status := errorStatus(err)
+		if status == http.StatusInternalServerError {
+			slog.Error("Failed to update like", "post_id", postID, "error", err)
+		}
 		if isHTMXRequest(r) {
 			w.Header().Set("Content-Type", "text/html")
-			fmt.Fprint(w, `<button class="like-btn">Not found</button>`)
+			w.WriteHeader(status)
+			fmt.Fprintf(w, `<button class="like-btn">%s</button>`, http.StatusText(status))
 			return
 		}
-		utils.Error(w, http.StatusNotFound, "Post not found")
-		return
-	}
-
-	if post.IsLiked {
-		err = h.userService.UnlikePost(currentUser.ID, postID)
-		post.IsLiked = false
-		if post.LikeCount > 0 {
-			post.LikeCount--
-		}
-	} else {
-		err = h.userService.LikePost(currentUser.ID, postID)
-		post.IsLiked = true
-		post.LikeCount++
-	}
-
-	if err != nil {
-		if isHTMXRequest(r) {
-			w.Header().Set("Content-Type", "text/html")
-			fmt.Fprint(w, `<button class="like-btn">Error</button>`)
+		if status == http.StatusNotFound {
+			utils.Error(w, status, "Post not found")
 			return
 		}
-		utils.Error(w, http.StatusInternalServerError, "Failed to update like")
+		utils.Error(w, status, "Failed to update like")
 		return
 	}
 
@@ -210,30 +260,13 @@ func (h *Handler) LikePostHandler(w http.ResponseWriter, r *http.Request) {
 
 	response := map[string]interface{}{
 		"success": true,
-		"liked":   post.IsLiked,
-		"likes":   post.LikeCount,
+		"liked":   liked,
+		"likes":   likes,
 	}
 
 	utils.Success(w, response)
 }
 
-func (h *Handler) GetPostsHandler(w http.ResponseWriter, r *http.Request) {
-	currentUser := h.getCurrentUser(r)
-	userID := 0
-
-	if currentUser != nil {
-		userID = currentUser.ID
-	}
-
-	posts, err := h.userService.GetRecentPosts(userID, 20)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to load posts")
-		return
-	}
-
-	utils.Success(w, posts)
-}
-
 func (h *Handler) GetCurrentUserHandler(w http.ResponseWriter, r *http.Request) {
 	currentUser := h.getCurrentUser(r)
 	if currentUser == nil {
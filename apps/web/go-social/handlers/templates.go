@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+
+	"github.com/dunamismax/go-stdlib/pkg/tracing"
+)
+
+// Templates holds one template set per page. Every page defines its own
+// "content" block for layout.html, so the pages cannot share a single set.
+type Templates struct {
+	pages map[string]*template.Template
+}
+
+// NewTemplates parses layout and the shared partials once and clones them
+// for each page, keyed by the page's file name (for example "home.html").
+func NewTemplates(funcs template.FuncMap, layout string, pages map[string]string, partials ...string) (*Templates, error) {
+	base, err := template.New("layout.html").Funcs(funcs).Parse(layout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse layout: %w", err)
+	}
+	for _, partial := range partials {
+		if _, err := base.Parse(partial); err != nil {
+			return nil, fmt.Errorf("failed to parse partials: %w", err)
+		}
+	}
+
+	t := &Templates{pages: make(map[string]*template.Template, len(pages))}
+	for name, page := range pages {
+		clone, err := base.Clone()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := clone.Parse(page); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		t.pages[name] = clone
+	}
+
+	return t, nil
+}
+
+// ExecuteTemplate renders a full page when name is a page file name, or a
+// named partial (such as "post") defined by any page. The output is
+// buffered, so on error nothing has been written and the caller can still
+// send an error response. Rendering is traced as a child of the span in ctx.
+func (t *Templates) ExecuteTemplate(ctx context.Context, w io.Writer, name string, data any) error {
+	_, span := tracing.Start(ctx, "template "+name)
+	defer span.End()
+
+	var buf bytes.Buffer
+	err := t.execute(&buf, name, data)
+	span.SetError(err)
+	if err != nil {
+		return err
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+func (t *Templates) execute(w io.Writer, name string, data any) error {
+	if page, ok := t.pages[name]; ok {
+		return page.ExecuteTemplate(w, "layout.html", data)
+	}
+
+	for _, page := range t.pages {
+		if partial := page.Lookup(name); partial != nil {
+			return partial.Execute(w, data)
+		}
+	}
+
+	return fmt.Errorf("template %q not found", name)
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/dunamismax/go-stdlib/apps/web/go-social/models"
+	"github.com/dunamismax/go-stdlib/pkg/database"
+	"github.com/dunamismax/go-stdlib/pkg/utils"
+)
+
+// pageSize is how many posts a timeline page holds, and the API's default.
+const pageSize = 20
+
+// maxPageSize bounds the API's ?limit=.
+const maxPageSize = 100
+
+// timelineFeed picks the home timeline: signed-in users get "following"
+// unless they ask for ?feed=everyone; visitors always get "everyone".
+func timelineFeed(r *http.Request, viewer *models.User) string {
+	if viewer == nil || r.URL.Query().Get("feed") == "everyone" {
+		return "everyone"
+	}
+	return "following"
+}
+
+// timelinePage loads a page of the home timeline named by feed.
+func (h *Handler) timelinePage(ctx context.Context, feed string, viewer *models.User, cursor string, limit int) (models.PostPage, error) {
+	if feed == "following" && viewer != nil {
+		return h.userService.GetFollowingPosts(ctx, viewer.ID, cursor, limit)
+	}
+	userID := 0
+	if viewer != nil {
+		userID = viewer.ID
+	}
+	return h.userService.GetRecentPosts(ctx, userID, cursor, limit)
+}
+
+// nextPageURL adds cursor to base's query string. An empty cursor means
+// there is no next page.
+func nextPageURL(base, cursor string) string {
+	if cursor == "" {
+		return ""
+	}
+	sep := "?"
+	if strings.Contains(base, "?") {
+		sep = "&"
+	}
+	return base + sep + "cursor=" + url.QueryEscape(cursor)
+}
+
+// renderPostPage answers an infinite-scroll request with the page's posts
+// followed by the trigger that loads the next one. follow is as for
+// postViews.
+func (h *Handler) renderPostPage(w http.ResponseWriter, r *http.Request, page models.PostPage, err error, base string, follow bool) {
+	if err != nil {
+		status := errorStatus(err)
+		if status == http.StatusInternalServerError {
+			slog.Error("Failed to load posts", "error", err)
+		}
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	data := PageData{
+		Posts:   postViews(page.Posts, h.getCurrentUser(r), follow),
+		NextURL: nextPageURL(base, page.NextCursor),
+	}
+	w.Header().Set("Content-Type", "text/html")
+	if err := h.templates.ExecuteTemplate(r.Context(), w, "post-page", data); err != nil {
+		slog.Error("Failed to render posts", "error", err)
+		http.Error(w, "Failed to render posts", http.StatusInternalServerError)
+	}
+}
+
+// GetPostsHandler pages through a timeline as JSON: ?feed=following for
+// the signed-in user's timeline, otherwise every post. ?cursor= takes the
+// next_cursor of the previous response and ?limit= the page size.
+func (h *Handler) GetPostsHandler(w http.ResponseWriter, r *http.Request) {
+	limit, ok := pageLimit(w, r)
+	if !ok {
+		return
+	}
+
+	currentUser := h.getCurrentUser(r)
+	feed := r.URL.Query().Get("feed")
+	if feed == "following" && currentUser == nil {
+		utils.Error(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	page, err := h.timelinePage(r.Context(), feed, currentUser, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		writePostsError(w, err)
+		return
+	}
+
+	utils.Success(w, page)
+}
+
+// GetUserPostsHandler pages through one user's posts as JSON, with the
+// same ?cursor= and ?limit= as GetPostsHandler.
+func (h *Handler) GetUserPostsHandler(w http.ResponseWriter, r *http.Request) {
+	limit, ok := pageLimit(w, r)
+	if !ok {
+		return
+	}
+
+	author, err := h.userService.GetUserByUsername(r.Context(), r.PathValue("username"))
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			utils.Error(w, http.StatusNotFound, "User not found")
+			return
+		}
+		slog.Error("Failed to load user", "error", err)
+		utils.Error(w, http.StatusInternalServerError, "Failed to load posts")
+		return
+	}
+
+	userID := 0
+	if currentUser := h.getCurrentUser(r); currentUser != nil {
+		userID = currentUser.ID
+	}
+
+	page, err := h.userService.GetUserPosts(r.Context(), author.ID, userID, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		writePostsError(w, err)
+		return
+	}
+
+	utils.Success(w, page)
+}
+
+// pageLimit reads ?limit=, writing a 400 and returning false when it is
+// not a number from 1 to maxPageSize.
+func pageLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return pageSize, true
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > maxPageSize {
+		utils.Error(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxPageSize))
+		return 0, false
+	}
+	return limit, true
+}
+
+func writePostsError(w http.ResponseWriter, err error) {
+	if errors.Is(err, database.ErrInvalidCursor) {
+		utils.Error(w, http.StatusBadRequest, "Invalid cursor")
+		return
+	}
+	slog.Error("Failed to load posts", "error", err)
+	utils.Error(w, http.StatusInternalServerError, "Failed to load posts")
+}
@@ -1,17 +1,30 @@
 package main
 
 import (
-	_ "embed"
+	"context"
+	"embed"
+	"errors"
+	"flag"
 	"html/template"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
+	"path/filepath"
 	"time"
 
+	"github.com/dunamismax/go-stdlib/apps/web/go-social/avatar"
 	"github.com/dunamismax/go-stdlib/apps/web/go-social/handlers"
 	"github.com/dunamismax/go-stdlib/apps/web/go-social/models"
+	"github.com/dunamismax/go-stdlib/pkg/auth"
+	"github.com/dunamismax/go-stdlib/pkg/config"
 	"github.com/dunamismax/go-stdlib/pkg/database"
+	"github.com/dunamismax/go-stdlib/pkg/health"
+	"github.com/dunamismax/go-stdlib/pkg/metrics"
+	"github.com/dunamismax/go-stdlib/pkg/middleware"
+	"github.com/dunamismax/go-stdlib/pkg/server"
+	"github.com/dunamismax/go-stdlib/pkg/tracing"
+	"github.com/dunamismax/go-stdlib/pkg/utils"
 )
 
 //go:embed dist
@@ -32,98 +45,243 @@ var registerTemplate string
 //go:embed templates/home.html
 var homeTemplate string
 
+//go:embed templates/follows.html
+var followsTemplate string
+
+//go:embed templates/profile.html
+var profileTemplate string
+
+//go:embed templates/settings.html
+var settingsTemplate string
+
+//go:embed templates/partials.html
+var partialsTemplate string
+
 func main() {
+	cfg := defaultConfig()
+	if err := config.Load(&cfg, config.Options{Args: os.Args[1:]}); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		log.Fatal("Failed to load configuration:", err)
+	}
+
 	// Setup structured logging
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
+		Level: cfg.LogLevel,
 	}))
 	slog.SetDefault(logger)
+	slog.Info("Configuration loaded", "config", config.Redacted(&cfg))
 
-	db, err := database.NewDB("./data")
+	tracer, err := tracing.New(cfg.Tracing)
+	if err != nil {
+		log.Fatal("Failed to start tracing:", err)
+	}
+	tracing.SetDefault(tracer)
+
+	cfg.Database.DataDir = cfg.DataDir
+	db, err := database.Open(cfg.Database)
 	if err != nil {
 		slog.Error("Failed to connect to database", "error", err)
 		log.Fatal("Failed to connect to database:", err)
 	}
-	defer db.Close()
 
 	if err := db.Migrate(); err != nil {
 		slog.Error("Failed to run migrations", "error", err)
 		log.Fatal("Failed to run migrations:", err)
 	}
 
-	userService := models.NewUserService(db)
+	keyring, err := cfg.sessionKeyring()
+	if err != nil {
+		slog.Error("Failed to load session keys", "error", err)
+		log.Fatal("Failed to load session keys:", err)
+	}
+	if keyring.ContainsSecret(utils.DefaultSessionSecret) {
+		if cfg.Env == "production" {
+			log.Fatal("Refusing to start in production with the default session secret; set SESSION_SECRET, SESSION_KEYS or SESSION_KEYS_FILE")
+		}
+		slog.Warn("Using the default session secret; set SESSION_SECRET before deploying")
+	}
+
+	userService := models.NewUserService(db, auth.DefaultPasswordHasher())
+
+	sessionStore, err := auth.NewSQLiteSessionStore(db)
+	if err != nil {
+		slog.Error("Failed to initialize session store", "error", err)
+		log.Fatal("Failed to initialize session store:", err)
+	}
+	sessions := auth.NewSessionManager(sessionStore, cfg.SessionIdleTimeout, cfg.SessionMaxLifetime)
+	stopCleanup := sessions.StartCleanup(cfg.SessionCleanup)
+	stopBackups := db.StartBackups(cfg.Backup)
+
+	// Uploaded avatars live beside the database unless configured elsewhere
+	if cfg.Avatar.Dir == "" {
+		cfg.Avatar.Dir = filepath.Join(cfg.DataDir, "avatars")
+	}
+	avatarDisk, err := avatar.NewDisk(cfg.Avatar.Dir)
+	if err != nil {
+		log.Fatal("Failed to initialize avatar storage:", err)
+	}
+	avatars := avatar.NewStore(avatarDisk, cfg.Avatar)
 
 	// Create templates
-	templates := template.New("").Funcs(template.FuncMap{
+	templates, err := handlers.NewTemplates(template.FuncMap{
 		"formatTime": func(t interface{}) string {
 			return "Jan 2, 2006"
 		},
-	})
-	templates = template.Must(templates.Parse(layoutTemplate))
-	templates = template.Must(templates.Parse(loginTemplate))
-	templates = template.Must(templates.Parse(registerTemplate))
-	templates = template.Must(templates.Parse(homeTemplate))
+		"avatar": avatar.URL,
+	}, layoutTemplate, map[string]string{
+		"login.html":    loginTemplate,
+		"register.html": registerTemplate,
+		"home.html":     homeTemplate,
+		"follows.html":  followsTemplate,
+		"profile.html":  profileTemplate,
+		"settings.html": settingsTemplate,
+	}, partialsTemplate)
+	if err != nil {
+		log.Fatal("Failed to parse templates:", err)
+	}
 
-	handler := handlers.NewHandler(userService, templates)
+	handler := handlers.NewHandler(userService, sessions, keyring, templates, avatars, cfg.HTTPS)
+
+	// Rate limit credential endpoints per client and route. State lives in
+	// SQLite so the limit holds across several processes sharing the database.
+	limitStore, err := middleware.NewSQLiteStore(db.GetConnection())
+	if err != nil {
+		log.Fatal("Failed to initialize rate limit store:", err)
+	}
+	authLimiter := middleware.NewLimiter(middleware.NewSlidingWindow(5, 5*time.Minute), limitStore)
+	authRateLimit := middleware.RateLimitWith(middleware.RateLimitConfig{
+		Limiter: authLimiter,
+		KeyFunc: middleware.KeyBy(middleware.KeyByIP, middleware.KeyByRoute),
+	})
 
 	mux := http.NewServeMux()
 
+	// Probes for the orchestrator; readiness also fails while draining
+	probes := health.New(health.Config{})
+	probes.AddCheck("database", db.Ping)
+	probes.Register(mux)
+
+	// Prometheus metrics for requests, the connection pool and the runtime
+	registry := metrics.NewRegistry()
+	metrics.RegisterRuntime(registry)
+	metrics.RegisterDBStats(registry, "write", db.GetConnection())
+	metrics.RegisterDBStats(registry, "read", db.ReadConnection())
+	httpMetrics := metrics.NewHTTPMetrics(registry)
+	mux.Handle("GET /metrics", registry.Handler())
+
 	// Static files - serve both old and new assets
 	mux.HandleFunc("GET /static/htmx.min.js", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/javascript")
 		w.Write(htmxJS)
 	})
 
+	// Avatars, uploaded or generated
+	mux.HandleFunc("GET /avatars/{file}", handler.ServeAvatarHandler)
+	mux.HandleFunc("GET /identicons/{file}", handler.IdenticonHandler)
+
 	// Serve built assets from Vite
 	assetsHandler := http.FileServer(http.FS(distFS))
 	mux.Handle("GET /assets/", http.StripPrefix("/", assetsHandler))
 
 	// Authentication routes
 	mux.HandleFunc("GET /login", handler.LoginPageHandler)
-	mux.HandleFunc("POST /login", handler.LoginHandler)
+	mux.Handle("POST /login", authRateLimit(http.HandlerFunc(handler.LoginHandler)))
 	mux.HandleFunc("GET /register", handler.RegisterPageHandler)
-	mux.HandleFunc("POST /register", handler.RegisterHandler)
+	mux.Handle("POST /register", authRateLimit(http.HandlerFunc(handler.RegisterHandler)))
 
 	// Other routes
 	mux.HandleFunc("POST /logout", handler.LogoutHandler)
+	mux.HandleFunc("POST /logout/all", handler.LogoutAllHandler)
+	mux.HandleFunc("POST /sessions/{id}/revoke", handler.RevokeSessionHandler)
 	mux.HandleFunc("POST /post", handler.CreatePostHandler)
 	mux.HandleFunc("POST /like/{postId}", handler.LikePostHandler)
+	mux.HandleFunc("GET /settings", handler.SettingsPageHandler)
+	mux.HandleFunc("POST /settings", handler.SettingsHandler)
+	mux.HandleFunc("POST /settings/avatar", handler.UploadAvatarHandler)
+	mux.HandleFunc("POST /settings/avatar/remove", handler.RemoveAvatarHandler)
+	mux.HandleFunc("GET /u/{username}", handler.ProfileHandler)
+	mux.HandleFunc("POST /u/{username}/follow", handler.FollowHandler)
+	mux.HandleFunc("POST /u/{username}/unfollow", handler.UnfollowHandler)
+	mux.HandleFunc("GET /u/{username}/followers", handler.FollowersHandler)
+	mux.HandleFunc("GET /u/{username}/following", handler.FollowingHandler)
 
 	// API endpoints
 	mux.HandleFunc("GET /api/posts", handler.GetPostsHandler)
+	mux.HandleFunc("GET /api/users/{username}/posts", handler.GetUserPostsHandler)
 	mux.HandleFunc("GET /api/user/me", handler.GetCurrentUserHandler)
+	mux.HandleFunc("GET /api/user/sessions", handler.GetSessionsHandler)
 
 	// Pages
 	mux.HandleFunc("GET /", handler.HomeHandler)
 
-	// Apply basic logging middleware
-	finalHandler := loggerMiddleware(mux)
-
-	server := &http.Server{
-		Addr:         ":8081",
-		Handler:      finalHandler,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  120 * time.Second,
+	// Resolve the client IP first so logging and rate limiting see the real
+	// address behind trusted proxies, then log, set security
+	// headers, recover panics and apply the CORS and CSRF policies
+	clientIPs, err := middleware.NewClientIPResolver(cfg.TrustedProxies...)
+	if err != nil {
+		log.Fatal("Invalid trusted proxies:", err)
 	}
-
-	slog.Info("GoSocial server starting", "port", ":8081")
-	if err := server.ListenAndServe(); err != nil {
-		slog.Error("Server failed to start", "error", err)
-		log.Fatal(err)
+	// Pages stay same-origin; the JSON API can be opened to other front ends
+	cors := middleware.CORSWith(middleware.CORSConfig{
+		Routes: map[string]middleware.CORSConfig{
+			"/api/*": {
+				AllowedOrigins:   cfg.CORSAllowedOrigins,
+				AllowedHeaders:   []string{"Content-Type", "X-CSRF-Token"},
+				ExposedHeaders:   []string{"X-Request-ID"},
+				AllowCredentials: true,
+				MaxAge:           time.Hour,
+			},
+		},
+	})
+	// Scripts must carry the per-request nonce; PageData passes it to templates
+	secureConfig := middleware.SecureHeadersConfig{
+		CSP: middleware.NewCSP().
+			Set("script-src", "'self'", middleware.CSPNonceSource).
+			Set("style-src", "'self'").
+			Set("img-src", "'self'", "data:"),
 	}
-}
+	if cfg.HTTPS {
+		secureConfig.HSTSMaxAge = 365 * 24 * time.Hour
+	}
+	secure := middleware.SecureHeaders(secureConfig)
+	csrf := middleware.CSRF(middleware.CSRFConfig{
+		Secure: cfg.HTTPS,
+	})
+	// Bound bodies before CSRF parses forms looking for its token; the
+	// margin over the avatar limit covers the multipart encoding
+	bodyLimit := middleware.MaxBodySize(cfg.Avatar.MaxBytes + 1<<20)
+	accessLog := middleware.AccessLog(middleware.AccessLogConfig{
+		ExcludePaths: []string{"/assets/*", "/static/*", "/avatars/*", "/identicons/*", "/healthz", "/readyz", "/metrics"},
+	})
+	route := metrics.MuxRoute(mux)
+	finalHandler := clientIPs.Middleware(tracer.Middleware(route)(httpMetrics.Middleware(route)(accessLog(secure(middleware.Recover(cors(bodyLimit(csrf(mux)))))))))
+
+	cfg.Server.Handler = finalHandler
+	srv := server.New(cfg.Server)
+	probes.AddCheck("server", health.ReadyCheck(srv.Ready))
 
-func loggerMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		next.ServeHTTP(w, r)
-		slog.Info("HTTP request",
-			"method", r.Method,
-			"path", r.URL.Path,
-			"remote_addr", r.RemoteAddr,
-			"duration", time.Since(start),
-			"user_agent", r.UserAgent(),
-		)
+	// Stop background work before closing the database it uses
+	srv.OnShutdown("session cleanup", func(ctx context.Context) error {
+		stopCleanup()
+		return nil
 	})
+	srv.OnShutdown("backups", func(ctx context.Context) error {
+		stopBackups()
+		return nil
+	})
+	srv.OnShutdown("rate limiter", func(ctx context.Context) error {
+		return authLimiter.Close()
+	})
+	srv.OnShutdown("database", func(ctx context.Context) error {
+		return db.Close()
+	})
+	srv.OnShutdown("tracing", tracer.Shutdown)
+
+	slog.Info("GoSocial server starting", "addr", cfg.Server.Addr)
+	if err := srv.Run(context.Background()); err != nil {
+		slog.Error("Server stopped with errors", "error", err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dunamismax/go-stdlib/pkg/database"
+)
+
+// ErrSelfFollow is returned when a user tries to follow themselves.
+var ErrSelfFollow = errors.New("cannot follow yourself")
+
+// Connection is a user in a followers or following list.
+type Connection struct {
+	User
+	// IsFollowing reports whether the viewer follows this user.
+	IsFollowing bool `json:"is_following"`
+}
+
+// FollowCounts is how many users follow a user and how many they follow.
+type FollowCounts struct {
+	Followers int `json:"followers"`
+	Following int `json:"following"`
+}
+
+// Follow makes followerID follow the user called username and returns
+// that user's new counts. Following someone twice is not an error.
+func (s *UserService) Follow(ctx context.Context, followerID int, username string) (FollowCounts, error) {
+	return s.setFollowing(ctx, followerID, username, true)
+}
+
+// Unfollow is the reverse of Follow.
+func (s *UserService) Unfollow(ctx context.Context, followerID int, username string) (FollowCounts, error) {
+	return s.setFollowing(ctx, followerID, username, false)
+}
+
+func (s *UserService) setFollowing(ctx context.Context, followerID int, username string, follow bool) (counts FollowCounts, err error) {
+	err = s.db.WithTx(ctx, func(tx database.Tx) error {
+		user, err := tx.Users().GetByUsername(ctx, username)
+		if err != nil {
+			return err
+		}
+		if user.ID == followerID {
+			return ErrSelfFollow
+		}
+
+		if follow {
+			err = tx.Follows().Follow(ctx, followerID, user.ID)
+		} else {
+			err = tx.Follows().Unfollow(ctx, followerID, user.ID)
+		}
+		if err != nil {
+			return err
+		}
+
+		counts.Followers, counts.Following, err = tx.Follows().Counts(ctx, user.ID)
+		return err
+	})
+	return counts, err
+}
+
+func (s *UserService) GetFollowCounts(ctx context.Context, userID int) (FollowCounts, error) {
+	followers, following, err := s.db.Follows().Counts(ctx, userID)
+	if err != nil {
+		return FollowCounts{}, err
+	}
+	return FollowCounts{Followers: followers, Following: following}, nil
+}
+
+// GetFollowers lists who follows userID, marking the ones viewerID follows.
+func (s *UserService) GetFollowers(ctx context.Context, userID, viewerID, limit int) ([]Connection, error) {
+	users, err := s.db.Follows().Followers(ctx, userID, viewerID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get followers: %w", err)
+	}
+	return connections(users), nil
+}
+
+// GetFollowing lists who userID follows, marking the ones viewerID follows.
+func (s *UserService) GetFollowing(ctx context.Context, userID, viewerID, limit int) ([]Connection, error) {
+	users, err := s.db.Follows().Following(ctx, userID, viewerID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get following: %w", err)
+	}
+	return connections(users), nil
+}
+
+func connections(users []database.FollowUser) []Connection {
+	result := make([]Connection, 0, len(users))
+	for _, user := range users {
+		result = append(result, Connection{
+			User: User{
+				ID:          user.ID,
+				Username:    user.Username,
+				DisplayName: user.DisplayName,
+				Bio:         user.Bio,
+				AvatarURL:   user.AvatarURL,
+				CreatedAt:   user.CreatedAt,
+				UpdatedAt:   user.UpdatedAt,
+			},
+			IsFollowing: user.IsFollowing,
+		})
+	}
+	return result
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"context"
+	"fmt"
+)
+
+// Profile is a user as their profile page shows them to a viewer.
+type Profile struct {
+	User
+	Counts FollowCounts `json:"counts"`
+	// IsFollowing reports whether the viewer follows this user.
+	IsFollowing bool `json:"is_following"`
+}
+
+// GetProfile looks up the user called username with their follow counts.
+// viewerID is the signed-in viewer, or 0.
+func (s *UserService) GetProfile(ctx context.Context, username string, viewerID int) (*Profile, error) {
+	user, err := s.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &Profile{User: *user}
+	profile.Counts, err = s.GetFollowCounts(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get follow counts: %w", err)
+	}
+	if viewerID != 0 && viewerID != user.ID {
+		profile.IsFollowing, err = s.db.Follows().IsFollowing(ctx, viewerID, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get follow state: %w", err)
+		}
+	}
+	return profile, nil
+}
+
+// UpdateProfile sets userID's display name and bio, which the caller has
+// validated, and returns the updated user.
+func (s *UserService) UpdateProfile(ctx context.Context, userID int, displayName, bio string) (*User, error) {
+	user, err := s.db.Users().UpdateProfile(ctx, userID, displayName, bio)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update profile: %w", err)
+	}
+
+	return &User{
+		ID:          user.ID,
+		Username:    user.Username,
+		Email:       user.Email,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		AvatarURL:   user.AvatarURL,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+	}, nil
+}
+
+// UpdateAvatar records avatarURL, from avatar.Store.Save, as userID's
+// avatar; "" goes back to the generated one.
+func (s *UserService) UpdateAvatar(ctx context.Context, userID int, avatarURL string) error {
+	if err := s.db.Users().UpdateAvatarURL(ctx, userID, avatarURL); err != nil {
+		return fmt.Errorf("failed to update avatar: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dunamismax/go-stdlib/pkg/database"
+)
+
+// PostPage is one page of a timeline. NextCursor resumes the timeline after
+// the page's last post and is empty on the last page.
+type PostPage struct {
+	Posts      []*Post `json:"posts"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// The timelines take the cursor of the page to resume after: "" for the
+// first page, then the previous page's NextCursor. A token that was not
+// issued as a NextCursor fails with database.ErrInvalidCursor. userID is
+// the viewer, for like and follow state, or 0.
+
+// GetRecentPosts is the Everyone timeline of all posts.
+func (s *UserService) GetRecentPosts(ctx context.Context, userID int, cursor string, limit int) (PostPage, error) {
+	return s.postPage(ctx, userID, cursor, limit, func(after database.Cursor, n int) ([]database.FeedPost, error) {
+		return s.db.Feed().Recent(ctx, userID, after, n)
+	})
+}
+
+// GetFollowingPosts is the personal timeline of userID's own posts and
+// those of everyone they follow.
+func (s *UserService) GetFollowingPosts(ctx context.Context, userID int, cursor string, limit int) (PostPage, error) {
+	return s.postPage(ctx, userID, cursor, limit, func(after database.Cursor, n int) ([]database.FeedPost, error) {
+		return s.db.Feed().Following(ctx, userID, after, n)
+	})
+}
+
+// GetUserPosts is the profile timeline of authorID's posts.
+func (s *UserService) GetUserPosts(ctx context.Context, authorID, userID int, cursor string, limit int) (PostPage, error) {
+	return s.postPage(ctx, userID, cursor, limit, func(after database.Cursor, n int) ([]database.FeedPost, error) {
+		return s.db.Feed().ByUser(ctx, authorID, userID, after, n)
+	})
+}
+
+func (s *UserService) postPage(ctx context.Context, userID int, cursor string, limit int, list func(after database.Cursor, n int) ([]database.FeedPost, error)) (PostPage, error) {
+	after, err := database.ParseCursor(cursor)
+	if err != nil {
+		return PostPage{}, err
+	}
+
+	// One post more than the page tells whether another page follows
+	posts, err := list(after, limit+1)
+	if err != nil {
+		return PostPage{}, fmt.Errorf("failed to get posts: %w", err)
+	}
+
+	var page PostPage
+	if len(posts) > limit {
+		posts = posts[:limit]
+		page.NextCursor = database.CursorAfter(posts[limit-1].Post).String()
+	}
+
+	page.Posts = make([]*Post, 0, len(posts))
+	for _, post := range posts {
+		page.Posts = append(page.Posts, &Post{
+			ID:          post.ID,
+			UserID:      post.UserID,
+			Content:     post.Content,
+			Username:    post.Username,
+			DisplayName: post.DisplayName,
+			AvatarURL:   post.AvatarURL,
+			CreatedAt:   post.CreatedAt,
+			UpdatedAt:   post.UpdatedAt,
+			LikeCount:   post.LikeCount,
+			IsLiked:     post.IsLiked,
+			IsFollowing: post.IsFollowing,
+		})
+	}
+	return page, nil
+}
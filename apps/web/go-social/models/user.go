@@ -1,12 +1,17 @@
 package models
 
 import (
-	"crypto/sha256"
+	"context"
+	"crypto/rand"
+	"errors"
 	"fmt"
+	"log/slog"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/dunamismax/go-stdlib/pkg/auth"
 	"github.com/dunamismax/go-stdlib/pkg/database"
 )
 
@@ -22,28 +27,44 @@ type User struct {
 }
 
 type Post struct {
-	ID        int       `json:"id"`
-	UserID    int       `json:"user_id"`
-	Content   string    `json:"content"`
-	Username  string    `json:"username"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	LikeCount int       `json:"like_count"`
-	IsLiked   bool      `json:"is_liked"`
+	ID          int       `json:"id"`
+	UserID      int       `json:"user_id"`
+	Content     string    `json:"content"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	AvatarURL   string    `json:"avatar_url"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	LikeCount   int       `json:"like_count"`
+	IsLiked     bool      `json:"is_liked"`
+	// IsFollowing reports whether the viewer follows the post's author.
+	IsFollowing bool `json:"is_following"`
 }
 
+// ErrInvalidCredentials is returned by AuthenticateUser.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
 type UserService struct {
-	db *database.DB
+	db     *database.DB
+	hasher auth.PasswordHasher
+
+	// decoy is verified against for unknown usernames, so they take as
+	// long to reject as wrong passwords. Built on first use by decoyHash.
+	decoyOnce sync.Once
+	decoy     string
 }
 
-func NewUserService(db *database.DB) *UserService {
-	return &UserService{db: db}
+func NewUserService(db *database.DB, hasher auth.PasswordHasher) *UserService {
+	return &UserService{db: db, hasher: hasher}
 }
 
-func (s *UserService) CreateUser(username, email, password, displayName string) (*User, error) {
-	hashedPassword := hashPassword(password)
+func (s *UserService) CreateUser(ctx context.Context, username, email, password, displayName string) (*User, error) {
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
 
-	user, err := s.db.CreateUser(username, email, hashedPassword)
+	user, err := s.db.Users().Create(ctx, username, email, displayName, hashedPassword)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
@@ -52,7 +73,7 @@ func (s *UserService) CreateUser(username, email, password, displayName string)
 		ID:          user.ID,
 		Username:    user.Username,
 		Email:       user.Email,
-		DisplayName: displayName,
+		DisplayName: user.DisplayName,
 		Bio:         user.Bio,
 		AvatarURL:   user.AvatarURL,
 		CreatedAt:   user.CreatedAt,
@@ -60,8 +81,8 @@ func (s *UserService) CreateUser(username, email, password, displayName string)
 	}, nil
 }
 
-func (s *UserService) GetUserByID(id int) (*User, error) {
-	user, err := s.db.GetUserByID(id)
+func (s *UserService) GetUserByID(ctx context.Context, id int) (*User, error) {
+	user, err := s.db.Users().GetByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -78,8 +99,8 @@ func (s *UserService) GetUserByID(id int) (*User, error) {
 	}, nil
 }
 
-func (s *UserService) GetUserByUsername(username string) (*User, error) {
-	user, err := s.db.GetUserByUsername(username)
+func (s *UserService) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	user, err := s.db.Users().GetByUsername(ctx, username)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -96,14 +117,50 @@ func (s *UserService) GetUserByUsername(username string) (*User, error) {
 	}, nil
 }
 
-func (s *UserService) AuthenticateUser(username, password string) (*User, error) {
-	user, err := s.db.GetUserByUsername(username)
+// decoyHash returns a hash of a random password made with the hasher's
+// current parameters.
+func (s *UserService) decoyHash() string {
+	s.decoyOnce.Do(func() {
+		hash, err := s.hasher.Hash(rand.Text())
+		if err != nil {
+			slog.Error("Failed to create decoy password hash", "error", err)
+			return
+		}
+		s.decoy = hash
+	})
+	return s.decoy
+}
+
+// AuthenticateUser returns ErrInvalidCredentials for an unknown username
+// or wrong password, and other errors only when the lookup itself fails.
+func (s *UserService) AuthenticateUser(ctx context.Context, username, password string) (*User, error) {
+	user, err := s.db.Users().GetByUsername(ctx, username)
+	if errors.Is(err, database.ErrNotFound) {
+		// Spend as long as a real check so timing does not reveal which
+		// usernames exist
+		s.hasher.Verify(password, s.decoyHash())
+		return nil, ErrInvalidCredentials
+	}
 	if err != nil {
-		return nil, fmt.Errorf("invalid credentials")
+		return nil, fmt.Errorf("failed to authenticate user: %w", err)
 	}
 
-	if !verifyPassword(password, user.PasswordHash) {
-		return nil, fmt.Errorf("invalid credentials")
+	ok, err := s.hasher.Verify(password, user.PasswordHash)
+	if err != nil {
+		slog.Error("Failed to verify password hash", "user_id", user.ID, "error", err)
+		return nil, ErrInvalidCredentials
+	}
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+
+	// Transparently upgrade hashes from older algorithms or weaker parameters
+	if s.hasher.NeedsRehash(user.PasswordHash) {
+		if newHash, err := s.hasher.Hash(password); err == nil {
+			if err := s.db.Users().UpdatePasswordHash(ctx, user.ID, newHash); err != nil {
+				slog.Warn("Failed to upgrade password hash", "user_id", user.ID, "error", err)
+			}
+		}
 	}
 
 	return &User{
@@ -118,145 +175,59 @@ func (s *UserService) AuthenticateUser(username, password string) (*User, error)
 	}, nil
 }
 
-func (s *UserService) CreatePost(userID int, content string) (*Post, error) {
-	post, err := s.db.CreatePost(userID, content)
+func (s *UserService) CreatePost(ctx context.Context, userID int, content string) (*Post, error) {
+	post, err := s.db.Posts().Create(ctx, userID, content)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create post: %w", err)
 	}
 
 	// Get username for the post
-	user, err := s.db.GetUserByID(userID)
+	user, err := s.db.Users().GetByID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
 	return &Post{
-		ID:        post.ID,
-		UserID:    post.UserID,
-		Content:   post.Content,
-		Username:  user.Username,
-		CreatedAt: post.CreatedAt,
-		UpdatedAt: post.UpdatedAt,
-		LikeCount: 0,
-		IsLiked:   false,
-	}, nil
-}
-
-func (s *UserService) GetPostByID(postID, userID int) (*Post, error) {
-	post, err := s.db.GetPostByID(postID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get post: %w", err)
-	}
-
-	// Get username for the post
-	user, err := s.db.GetUserByID(post.UserID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get user: %w", err)
-	}
-
-	// Get like count and check if current user liked it
-	likeCount, err := s.db.GetLikeCount(postID)
-	if err != nil {
-		likeCount = 0
-	}
-
-	isLiked, err := s.db.IsPostLiked(userID, postID)
-	if err != nil {
-		isLiked = false
-	}
-
-	return &Post{
-		ID:        post.ID,
-		UserID:    post.UserID,
-		Content:   post.Content,
-		Username:  user.Username,
-		CreatedAt: post.CreatedAt,
-		UpdatedAt: post.UpdatedAt,
-		LikeCount: likeCount,
-		IsLiked:   isLiked,
+		ID:          post.ID,
+		UserID:      post.UserID,
+		Content:     post.Content,
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		AvatarURL:   user.AvatarURL,
+		CreatedAt:   post.CreatedAt,
+		UpdatedAt:   post.UpdatedAt,
+		LikeCount:   0,
+		IsLiked:     false,
 	}, nil
 }
 
-func (s *UserService) GetRecentPosts(userID int, limit int) ([]*Post, error) {
-	posts, err := s.db.GetRecentPosts(limit)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get posts: %w", err)
-	}
-
-	var result []*Post
-	for _, post := range posts {
-		// Get username for each post
-		user, err := s.db.GetUserByID(post.UserID)
-		if err != nil {
-			continue // Skip posts with invalid users
+// ToggleLike likes the post if userID has not yet, or removes the like,
+// and returns the resulting state. The check and the write share one
+// transaction so concurrent toggles cannot both see "not liked".
+func (s *UserService) ToggleLike(ctx context.Context, userID, postID int) (liked bool, count int, err error) {
+	err = s.db.WithTx(ctx, func(tx database.Tx) error {
+		if _, err := tx.Posts().GetByID(ctx, postID); err != nil {
+			return err
 		}
 
-		// Calculate like count and check if current user liked it
-		likeCount, err := s.db.GetLikeCount(post.ID)
+		isLiked, err := tx.Likes().IsLiked(ctx, userID, postID)
 		if err != nil {
-			likeCount = 0
+			return err
+		}
+		if isLiked {
+			err = tx.Likes().Unlike(ctx, userID, postID)
+		} else {
+			err = tx.Likes().Like(ctx, userID, postID)
 		}
-
-		isLiked, err := s.db.IsPostLiked(userID, post.ID)
 		if err != nil {
-			isLiked = false
+			return err
 		}
 
-		result = append(result, &Post{
-			ID:        post.ID,
-			UserID:    post.UserID,
-			Content:   post.Content,
-			Username:  user.Username,
-			CreatedAt: post.CreatedAt,
-			UpdatedAt: post.UpdatedAt,
-			LikeCount: likeCount,
-			IsLiked:   isLiked,
-		})
-	}
-
-	return result, nil
-}
-
-func (s *UserService) LikePost(userID, postID int) error {
-	return s.db.LikePost(userID, postID)
-}
-
-func (s *UserService) UnlikePost(userID, postID int) error {
-	return s.db.UnlikePost(userID, postID)
-}
-
-// Helper functions
-func hashPassword(password string) string {
-	hash := sha256.Sum256([]byte(password))
-	return fmt.Sprintf("%x", hash)
-}
-
-func verifyPassword(password, hash string) bool {
-	return hashPassword(password) == hash
-}
-
-// Simple session management using a global map (not production-ready)
-var sessions = make(map[string]*User)
-
-func (s *UserService) CreateSession(user *User) string {
-	sessionID := generateSessionID(user.ID)
-	sessions[sessionID] = user
-	return sessionID
-}
-
-func (s *UserService) GetUserFromSession(sessionID string) *User {
-	return sessions[sessionID]
-}
-
-func (s *UserService) DeleteSession(sessionID string) {
-	delete(sessions, sessionID)
-}
-
-func generateSessionID(userID int) string {
-	timestamp := time.Now().UnixNano()
-	data := fmt.Sprintf("%d-%d", userID, timestamp)
-	hash := sha256.Sum256([]byte(data))
-	return fmt.Sprintf("%x", hash)[:32]
+		liked = !isLiked
+		count, err = tx.Likes().Count(ctx, postID)
+		return err
+	})
+	return liked, count, err
 }
 
 func ExtractUserIDFromPath(path string) (int, error) {
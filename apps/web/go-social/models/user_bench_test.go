@@ -0,0 +1,113 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dunamismax/go-stdlib/pkg/database"
+)
+
+// BenchmarkGetRecentPosts measures the home feed while a background writer
+// keeps posting, comparing the rollback journal with a single reader
+// against the WAL and read pool defaults.
+//
+//	go test -run=^$ -bench=GetRecentPosts ./models
+func BenchmarkGetRecentPosts(b *testing.B) {
+	// Keep Open's startup logs out of the benchmark output for benchstat.
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	configs := []struct {
+		name   string
+		config database.Config
+	}{
+		{"delete-1-reader", database.Config{JournalMode: "delete", ReadConns: 1}},
+		{"wal-default", database.Config{}},
+	}
+
+	for _, tc := range configs {
+		b.Run(tc.name, func(b *testing.B) {
+			cfg := tc.config
+			cfg.DataDir = b.TempDir()
+			service, userIDs := newFeedBenchService(b, cfg)
+			ctx := context.Background()
+
+			stop := make(chan struct{})
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; ; i++ {
+					select {
+					case <-stop:
+						return
+					case <-time.After(time.Millisecond):
+					}
+					if _, err := service.CreatePost(ctx, userIDs[i%len(userIDs)], "background post"); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			}()
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := service.GetRecentPosts(ctx, userIDs[0], "", 20); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+			b.StopTimer()
+
+			close(stop)
+			wg.Wait()
+		})
+	}
+}
+
+func newFeedBenchService(b *testing.B, cfg database.Config) (*UserService, []int) {
+	b.Helper()
+	ctx := context.Background()
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+	if err := db.Migrate(); err != nil {
+		b.Fatal(err)
+	}
+
+	var userIDs []int
+	err = db.WithTx(ctx, func(tx database.Tx) error {
+		for i := range 50 {
+			user, err := tx.Users().Create(ctx, fmt.Sprintf("user%d", i), fmt.Sprintf("user%d@example.com", i), "", "hash")
+			if err != nil {
+				return err
+			}
+			userIDs = append(userIDs, user.ID)
+		}
+		for i := range 1000 {
+			post, err := tx.Posts().Create(ctx, userIDs[i%len(userIDs)], fmt.Sprintf("post %d", i))
+			if err != nil {
+				return err
+			}
+			for _, userID := range userIDs[:i%5] {
+				if err := tx.Likes().Like(ctx, userID, post.ID); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return NewUserService(db, nil), userIDs
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/dunamismax/go-stdlib/pkg/database"
+	"github.com/dunamismax/go-stdlib/pkg/server"
+	"github.com/dunamismax/go-stdlib/pkg/tracing"
+)
+
+// Config is loaded by config.Load from defaultConfig, an optional file, the
+// environment and flags. Run with -h to list every setting.
+type Config struct {
+	LogLevel           slog.Level      `config:"log_level" usage:"minimum log level (DEBUG, INFO, WARN, ERROR)"`
+	DataDir            string          `config:"data_dir" usage:"directory holding the SQLite database"`
+	CORSAllowedOrigins []string        `config:"cors_allowed_origins" usage:"origins that may call the public APIs"`
+	Database           database.Config `config:"db"`
+	Server             server.Config   `config:"server"`
+	Tracing            tracing.Config  `config:"tracing"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		LogLevel:           slog.LevelInfo,
+		DataDir:            "./data",
+		CORSAllowedOrigins: []string{"*"},
+		Server:             server.Config{Addr: ":8082"}.WithDefaults(),
+		Tracing:            tracing.Config{ServiceName: "gohyperdocs"},
+	}
+}
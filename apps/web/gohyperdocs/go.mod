@@ -3,7 +3,13 @@ module github.com/dunamismax/go-stdlib/apps/web/gohyperdocs
 go 1.24
 
 require (
+	github.com/dunamismax/go-stdlib/pkg/config v0.0.0
 	github.com/dunamismax/go-stdlib/pkg/database v0.0.0
+	github.com/dunamismax/go-stdlib/pkg/health v0.0.0
+	github.com/dunamismax/go-stdlib/pkg/metrics v0.0.0
+	github.com/dunamismax/go-stdlib/pkg/middleware v0.0.0
+	github.com/dunamismax/go-stdlib/pkg/server v0.0.0
+	github.com/dunamismax/go-stdlib/pkg/tracing v0.0.0
 	github.com/dunamismax/go-stdlib/pkg/utils v0.0.0
 )
 
@@ -24,6 +30,18 @@ require (
 	modernc.org/token v1.1.0 // indirect
 )
 
+replace github.com/dunamismax/go-stdlib/pkg/config => ../../../pkg/config
+
 replace github.com/dunamismax/go-stdlib/pkg/database => ../../../pkg/database
 
+replace github.com/dunamismax/go-stdlib/pkg/health => ../../../pkg/health
+
+replace github.com/dunamismax/go-stdlib/pkg/metrics => ../../../pkg/metrics
+
+replace github.com/dunamismax/go-stdlib/pkg/middleware => ../../../pkg/middleware
+
+replace github.com/dunamismax/go-stdlib/pkg/server => ../../../pkg/server
+
+replace github.com/dunamismax/go-stdlib/pkg/tracing => ../../../pkg/tracing
+
 replace github.com/dunamismax/go-stdlib/pkg/utils => ../../../pkg/utils
@@ -2,14 +2,21 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"net/http"
 	"regexp"
+	"runtime"
 	"strings"
 	"time"
 
 	"github.com/dunamismax/go-stdlib/apps/web/gohyperdocs/models"
+	"github.com/dunamismax/go-stdlib/pkg/database"
+	"github.com/dunamismax/go-stdlib/pkg/metrics"
+	"github.com/dunamismax/go-stdlib/pkg/middleware"
+	"github.com/dunamismax/go-stdlib/pkg/tracing"
 	"github.com/dunamismax/go-stdlib/pkg/utils"
 )
 
@@ -21,17 +28,29 @@ var (
 type DocsHandler struct {
 	docsService *models.DocsService
 	templates   *template.Template
+	httpMetrics *metrics.HTTPMetrics
 }
 
-func NewDocsHandler(docsService *models.DocsService, templates *template.Template) *DocsHandler {
+func NewDocsHandler(docsService *models.DocsService, templates *template.Template, httpMetrics *metrics.HTTPMetrics) *DocsHandler {
 	return &DocsHandler{
 		docsService: docsService,
 		templates:   templates,
+		httpMetrics: httpMetrics,
 	}
 }
 
+// render executes the named template, traced as part of r.
+func (h *DocsHandler) render(r *http.Request, w io.Writer, name string, data any) error {
+	_, span := tracing.Start(r.Context(), "template "+name)
+	defer span.End()
+
+	err := h.templates.ExecuteTemplate(w, name, data)
+	span.SetError(err)
+	return err
+}
+
 func (h *DocsHandler) HomeHandler(w http.ResponseWriter, r *http.Request) {
-	sections, err := h.docsService.GetAllSections()
+	sections, err := h.docsService.GetAllSections(r.Context())
 	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to load documentation")
 		return
@@ -47,10 +66,11 @@ func (h *DocsHandler) HomeHandler(w http.ResponseWriter, r *http.Request) {
 		"Title":      "GoHyperDocs - The Ultimate Go Hypermedia Documentation",
 		"Categories": categories,
 		"Sections":   sections,
+		"CSPNonce":   middleware.CSPNonce(r),
 	}
 
 	w.Header().Set("Content-Type", "text/html")
-	if err := h.templates.ExecuteTemplate(w, "home.html", data); err != nil {
+	if err := h.render(r, w, "home.html", data); err != nil {
 		utils.Error(w, http.StatusInternalServerError, fmt.Sprintf("Failed to render template: %v", err))
 		return
 	}
@@ -63,14 +83,18 @@ func (h *DocsHandler) SectionHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	section, err := h.docsService.GetSectionBySlug(slug)
-	if err != nil {
+	section, err := h.docsService.GetSectionBySlug(r.Context(), slug)
+	if errors.Is(err, database.ErrNotFound) {
 		utils.Error(w, http.StatusNotFound, "Section not found")
 		return
 	}
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to load section")
+		return
+	}
 
 	// Get all sections for navigation
-	allSections, err := h.docsService.GetAllSections()
+	allSections, err := h.docsService.GetAllSections(r.Context())
 	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to load navigation")
 		return
@@ -86,10 +110,11 @@ func (h *DocsHandler) SectionHandler(w http.ResponseWriter, r *http.Request) {
 		"Title":      fmt.Sprintf("%s - GoHyperDocs", section.Title),
 		"Section":    section,
 		"Categories": categories,
+		"CSPNonce":   middleware.CSPNonce(r),
 	}
 
 	w.Header().Set("Content-Type", "text/html")
-	if err := h.templates.ExecuteTemplate(w, "section.html", data); err != nil {
+	if err := h.render(r, w, "section.html", data); err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to render template")
 		return
 	}
@@ -102,14 +127,14 @@ func (h *DocsHandler) CategoryHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sections, err := h.docsService.GetSectionsByCategory(category)
+	sections, err := h.docsService.GetSectionsByCategory(r.Context(), category)
 	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to load category sections")
 		return
 	}
 
 	// Get all sections for navigation
-	allSections, err := h.docsService.GetAllSections()
+	allSections, err := h.docsService.GetAllSections(r.Context())
 	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to load navigation")
 		return
@@ -126,10 +151,11 @@ func (h *DocsHandler) CategoryHandler(w http.ResponseWriter, r *http.Request) {
 		"Category":   category,
 		"Sections":   sections,
 		"Categories": categories,
+		"CSPNonce":   middleware.CSPNonce(r),
 	}
 
 	w.Header().Set("Content-Type", "text/html")
-	if err := h.templates.ExecuteTemplate(w, "category.html", data); err != nil {
+	if err := h.render(r, w, "category.html", data); err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to render template")
 		return
 	}
@@ -144,11 +170,11 @@ func (h *DocsHandler) SearchHandler(w http.ResponseWriter, r *http.Request) {
 			"Results": []models.DocSection{},
 		}
 		w.Header().Set("Content-Type", "text/html")
-		h.templates.ExecuteTemplate(w, "search-results.html", data)
+		h.render(r, w, "search-results.html", data)
 		return
 	}
 
-	sections, err := h.docsService.SearchSections(query)
+	sections, err := h.docsService.SearchSections(r.Context(), query)
 	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Search failed")
 		return
@@ -160,7 +186,7 @@ func (h *DocsHandler) SearchHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "text/html")
-	if err := h.templates.ExecuteTemplate(w, "search-results.html", data); err != nil {
+	if err := h.render(r, w, "search-results.html", data); err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to render search results")
 		return
 	}
@@ -173,18 +199,22 @@ func (h *DocsHandler) LazyLoadDetailsHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	section, err := h.docsService.GetSectionBySlug(slug)
-	if err != nil {
+	section, err := h.docsService.GetSectionBySlug(r.Context(), slug)
+	if errors.Is(err, database.ErrNotFound) {
 		utils.Error(w, http.StatusNotFound, "Section not found")
 		return
 	}
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to load section")
+		return
+	}
 
 	data := map[string]interface{}{
 		"Section": section,
 	}
 
 	w.Header().Set("Content-Type", "text/html")
-	if err := h.templates.ExecuteTemplate(w, "section-details.html", data); err != nil {
+	if err := h.render(r, w, "section-details.html", data); err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to render details")
 		return
 	}
@@ -204,8 +234,8 @@ func (h *DocsHandler) CopyHandler(w http.ResponseWriter, r *http.Request) {
 
 // Interactive demonstration handlers
 func (h *DocsHandler) LiveCounterHandler(w http.ResponseWriter, r *http.Request) {
-	// Simulate a live counter that increments
-	counterValue := 42 + (time.Now().Unix() % 100)
+	// Real numbers from the metrics middleware; this request is in flight
+	stats := h.httpMetrics.Snapshot()
 
 	w.Header().Set("Content-Type", "text/html")
 	fmt.Fprintf(w, `
@@ -213,24 +243,30 @@ func (h *DocsHandler) LiveCounterHandler(w http.ResponseWriter, r *http.Request)
 		<h4>Live Server Stats</h4>
 		<div class="stats-grid">
 			<div class="stat-item">
-				<span class="stat-label">Active Users</span>
+				<span class="stat-label">In-Flight Requests</span>
 				<span class="stat-value">%d</span>
 			</div>
 			<div class="stat-item">
-				<span class="stat-label">Requests/min</span>
+				<span class="stat-label">Requests (last min)</span>
 				<span class="stat-value">%d</span>
 			</div>
 			<div class="stat-item">
-				<span class="stat-label">Response Time</span>
-				<span class="stat-value">%.1fms</span>
+				<span class="stat-label">Mean Response Time</span>
+				<span class="stat-value">%.2fms</span>
+			</div>
+			<div class="stat-item">
+				<span class="stat-label">Goroutines</span>
+				<span class="stat-value">%d</span>
 			</div>
 		</div>
-		<div class="update-timestamp">Updated: %s</div>
+		<div class="update-timestamp">Updated: %s &middot; %d requests since start</div>
 	</div>`,
-		counterValue,
-		150+(time.Now().Unix()%50),
-		0.2+float64(time.Now().Unix()%10)/10.0,
-		time.Now().Format("15:04:05"))
+		stats.InFlight,
+		stats.RequestsLastMinute,
+		float64(stats.MeanLatency.Microseconds())/1000,
+		runtime.NumGoroutine(),
+		time.Now().Format("15:04:05"),
+		stats.Requests)
 }
 
 func (h *DocsHandler) FormValidationHandler(w http.ResponseWriter, r *http.Request) {
@@ -340,7 +376,7 @@ func (h *DocsHandler) TabDemoHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *DocsHandler) APIDocsHandler(w http.ResponseWriter, r *http.Request) {
-	sections, err := h.docsService.GetAllSections()
+	sections, err := h.docsService.GetAllSections(r.Context())
 	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to load documentation")
 		return
@@ -359,11 +395,15 @@ func (h *DocsHandler) APISectionHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	section, err := h.docsService.GetSectionBySlug(slug)
-	if err != nil {
+	section, err := h.docsService.GetSectionBySlug(r.Context(), slug)
+	if errors.Is(err, database.ErrNotFound) {
 		utils.Error(w, http.StatusNotFound, "Section not found")
 		return
 	}
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to load section")
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(section)
@@ -385,7 +425,7 @@ func (h *DocsHandler) TodoDemoHandler(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/html")
 		fmt.Fprint(w, `
 		<div class="todo-demo">
-			<form hx-post="/api/demo/todo" hx-target="#todo-list" hx-swap="beforeend" hx-on::after-request="this.reset()">
+			<form hx-post="/api/demo/todo" hx-target="#todo-list" hx-swap="beforeend" data-reset-on-success>
 				<input name="task" type="text" placeholder="Add a new task..." required maxlength="100">
 				<button type="submit">Add Task</button>
 			</form>
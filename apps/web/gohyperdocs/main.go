@@ -1,17 +1,25 @@
 package main
 
 import (
+	"context"
 	_ "embed"
+	"errors"
+	"flag"
 	"html/template"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
-	"time"
 
 	"github.com/dunamismax/go-stdlib/apps/web/gohyperdocs/handlers"
 	"github.com/dunamismax/go-stdlib/apps/web/gohyperdocs/models"
+	"github.com/dunamismax/go-stdlib/pkg/config"
 	"github.com/dunamismax/go-stdlib/pkg/database"
+	"github.com/dunamismax/go-stdlib/pkg/health"
+	"github.com/dunamismax/go-stdlib/pkg/metrics"
+	"github.com/dunamismax/go-stdlib/pkg/middleware"
+	"github.com/dunamismax/go-stdlib/pkg/server"
+	"github.com/dunamismax/go-stdlib/pkg/tracing"
 )
 
 //go:embed static/main.js
@@ -42,34 +50,49 @@ var sectionDetailsTemplate string
 var layoutTemplate string
 
 func main() {
+	cfg := defaultConfig()
+	if err := config.Load(&cfg, config.Options{Args: os.Args[1:]}); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		log.Fatal("Failed to load configuration:", err)
+	}
+
 	// Setup structured logging
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
+		Level: cfg.LogLevel,
 	}))
 	slog.SetDefault(logger)
+	slog.Info("Configuration loaded", "config", config.Redacted(&cfg))
+
+	tracer, err := tracing.New(cfg.Tracing)
+	if err != nil {
+		log.Fatal("Failed to start tracing:", err)
+	}
+	tracing.SetDefault(tracer)
 
 	// Initialize database
-	db, err := database.NewDB("./data")
+	cfg.Database.DataDir = cfg.DataDir
+	db, err := database.Open(cfg.Database)
 	if err != nil {
 		slog.Error("Failed to connect to database", "error", err)
 		log.Fatal("Failed to connect to database:", err)
 	}
-	defer db.Close()
 
 	if err := db.Migrate(); err != nil {
 		slog.Error("Failed to run migrations", "error", err)
 		log.Fatal("Failed to run migrations:", err)
 	}
 
-	// Initialize docs service and create tables
+	// Initialize docs service and apply its migrations
 	docsService := models.NewDocsService(db)
-	if err := docsService.CreateTables(); err != nil {
-		slog.Error("Failed to create tables", "error", err)
-		log.Fatal("Failed to create tables:", err)
+	if err := docsService.Migrate(); err != nil {
+		slog.Error("Failed to run docs migrations", "error", err)
+		log.Fatal("Failed to run docs migrations:", err)
 	}
 
 	// Seed data
-	if err := docsService.SeedData(); err != nil {
+	if err := docsService.SeedData(context.Background()); err != nil {
 		slog.Error("Failed to seed data", "error", err)
 		log.Fatal("Failed to seed data:", err)
 	}
@@ -91,12 +114,26 @@ func main() {
 	templates = template.Must(templates.Parse(searchResultsTemplate))
 	templates = template.Must(templates.Parse(sectionDetailsTemplate))
 
+	// Prometheus metrics for requests, the connection pool and the runtime;
+	// the live counter demo reads the request totals back
+	registry := metrics.NewRegistry()
+	metrics.RegisterRuntime(registry)
+	metrics.RegisterDBStats(registry, "write", db.GetConnection())
+	metrics.RegisterDBStats(registry, "read", db.ReadConnection())
+	httpMetrics := metrics.NewHTTPMetrics(registry)
+
 	// Initialize handlers
-	docsHandler := handlers.NewDocsHandler(docsService, templates)
+	docsHandler := handlers.NewDocsHandler(docsService, templates, httpMetrics)
 
 	// Setup routes
 	mux := http.NewServeMux()
 
+	// Probes for the orchestrator; readiness also fails while draining
+	probes := health.New(health.Config{})
+	probes.AddCheck("database", db.Ping)
+	probes.Register(mux)
+	mux.Handle("GET /metrics", registry.Handler())
+
 	// Static files
 	mux.HandleFunc("GET /static/main.js", func(w http.ResponseWriter, r *http.Request) {
 		docsHandler.ServeStatic(w, r, mainJS, "application/javascript")
@@ -132,48 +169,37 @@ func main() {
 	mux.HandleFunc("GET /category/{category}", docsHandler.CategoryHandler)
 
 	// Apply middleware
-	finalHandler := corsMiddleware(loggerMiddleware(mux))
-
-	server := &http.Server{
-		Addr:         ":8082",
-		Handler:      finalHandler,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  120 * time.Second,
-	}
-
-	slog.Info("GoHyperDocs server starting", "port", ":8082", "url", "http://localhost:8082")
-	if err := server.ListenAndServe(); err != nil {
-		slog.Error("Server failed to start", "error", err)
-		log.Fatal(err)
-	}
-}
-
-func loggerMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		next.ServeHTTP(w, r)
-		slog.Info("HTTP request",
-			"method", r.Method,
-			"path", r.URL.Path,
-			"remote_addr", r.RemoteAddr,
-			"duration", time.Since(start),
-			"user_agent", r.UserAgent(),
-		)
+	accessLog := middleware.AccessLog(middleware.AccessLogConfig{
+		ExcludePaths: []string{"/static/*", "/healthz", "/readyz", "/metrics"},
 	})
-}
-
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		next.ServeHTTP(w, r)
+	// The docs and demo APIs are public and read without credentials
+	cors := middleware.CORSWith(middleware.CORSConfig{
+		AllowedOrigins: cfg.CORSAllowedOrigins,
+		AllowedHeaders: []string{"Content-Type", "Authorization"},
+	})
+	// Inline scripts carry the per-request nonce. The docs pages use inline
+	// style attributes throughout, so styles stay permissive.
+	secure := middleware.SecureHeaders(middleware.SecureHeadersConfig{
+		CSP: middleware.NewCSP().
+			Set("script-src", "'self'", middleware.CSPNonceSource).
+			Set("style-src", "'self'", "'unsafe-inline'", "https://fonts.googleapis.com").
+			Set("font-src", "'self'", "https://fonts.gstatic.com").
+			Set("img-src", "'self'", "data:"),
+	})
+	route := metrics.MuxRoute(mux)
+	finalHandler := tracer.Middleware(route)(httpMetrics.Middleware(route)(accessLog(secure(middleware.Recover(cors(mux))))))
+
+	cfg.Server.Handler = finalHandler
+	srv := server.New(cfg.Server)
+	probes.AddCheck("server", health.ReadyCheck(srv.Ready))
+	srv.OnShutdown("database", func(ctx context.Context) error {
+		return db.Close()
 	})
+	srv.OnShutdown("tracing", tracer.Shutdown)
+
+	slog.Info("GoHyperDocs server starting", "addr", cfg.Server.Addr)
+	if err := srv.Run(context.Background()); err != nil {
+		slog.Error("Server stopped with errors", "error", err)
+		os.Exit(1)
+	}
 }
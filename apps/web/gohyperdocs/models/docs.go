@@ -1,24 +1,20 @@
 package models
 
 import (
-	"fmt"
-	"time"
+	"context"
+	"embed"
 
 	"github.com/dunamismax/go-stdlib/pkg/database"
 )
 
-type DocSection struct {
-	ID          int       `json:"id"`
-	Title       string    `json:"title"`
-	Slug        string    `json:"slug"`
-	Content     string    `json:"content"`
-	CodeExample string    `json:"code_example"`
-	Category    string    `json:"category"`
-	Order       int       `json:"order"`
-	Searchable  string    `json:"searchable"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-}
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// MigrationSet is the name gohyperdocs' schema is tracked under in schema_migrations.
+const MigrationSet = "gohyperdocs"
+
+// DocSection is stored through database.DocSections.
+type DocSection = database.DocSection
 
 type DocsService struct {
 	db *database.DB
@@ -28,161 +24,40 @@ func NewDocsService(db *database.DB) *DocsService {
 	return &DocsService{db: db}
 }
 
-func (s *DocsService) CreateTables() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS doc_sections (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		title TEXT NOT NULL,
-		slug TEXT UNIQUE NOT NULL,
-		content TEXT NOT NULL,
-		code_example TEXT,
-		category TEXT NOT NULL,
-		order_num INTEGER DEFAULT 0,
-		searchable TEXT,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_doc_sections_slug ON doc_sections(slug);
-	CREATE INDEX IF NOT EXISTS idx_doc_sections_category ON doc_sections(category);
-	CREATE INDEX IF NOT EXISTS idx_doc_sections_order ON doc_sections(order_num);
-	`
-
-	_, err := s.db.GetConnection().Exec(query)
-	return err
+// Migrate applies the pending gohyperdocs schema migrations.
+func (s *DocsService) Migrate() error {
+	return s.db.RunMigrations(MigrationSet, migrationsFS, "migrations")
 }
 
-func (s *DocsService) GetAllSections() ([]DocSection, error) {
-	query := `
-	SELECT id, title, slug, content, COALESCE(code_example, ''), category, 
-	       order_num, COALESCE(searchable, ''), created_at, updated_at
-	FROM doc_sections 
-	ORDER BY category, order_num, title
-	`
-
-	rows, err := s.db.GetConnection().Query(query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var sections []DocSection
-	for rows.Next() {
-		var section DocSection
-		err := rows.Scan(
-			&section.ID, &section.Title, &section.Slug, &section.Content,
-			&section.CodeExample, &section.Category, &section.Order,
-			&section.Searchable, &section.CreatedAt, &section.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		sections = append(sections, section)
-	}
-
-	return sections, rows.Err()
+func (s *DocsService) GetAllSections(ctx context.Context) ([]DocSection, error) {
+	return s.db.DocSections().All(ctx)
 }
 
-func (s *DocsService) GetSectionBySlug(slug string) (*DocSection, error) {
-	query := `
-	SELECT id, title, slug, content, COALESCE(code_example, ''), category, 
-	       order_num, COALESCE(searchable, ''), created_at, updated_at
-	FROM doc_sections 
-	WHERE slug = ?
-	`
-
-	var section DocSection
-	err := s.db.GetConnection().QueryRow(query, slug).Scan(
-		&section.ID, &section.Title, &section.Slug, &section.Content,
-		&section.CodeExample, &section.Category, &section.Order,
-		&section.Searchable, &section.CreatedAt, &section.UpdatedAt,
-	)
-
-	if err != nil {
-		return nil, err
-	}
-
-	return &section, nil
+// GetSectionBySlug returns an error matching database.ErrNotFound for an
+// unknown slug.
+func (s *DocsService) GetSectionBySlug(ctx context.Context, slug string) (*DocSection, error) {
+	return s.db.DocSections().GetBySlug(ctx, slug)
 }
 
-func (s *DocsService) SearchSections(query string) ([]DocSection, error) {
-	searchQuery := `
-	SELECT id, title, slug, content, COALESCE(code_example, ''), category, 
-	       order_num, COALESCE(searchable, ''), created_at, updated_at
-	FROM doc_sections 
-	WHERE title LIKE ? OR content LIKE ? OR searchable LIKE ?
-	ORDER BY 
-		CASE 
-			WHEN title LIKE ? THEN 1
-			WHEN content LIKE ? THEN 2
-			ELSE 3
-		END,
-		category, order_num, title
-	LIMIT 50
-	`
-
-	searchTerm := "%" + query + "%"
-	titleMatch := "%" + query + "%"
-
-	rows, err := s.db.GetConnection().Query(searchQuery, searchTerm, searchTerm, searchTerm, titleMatch, titleMatch)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var sections []DocSection
-	for rows.Next() {
-		var section DocSection
-		err := rows.Scan(
-			&section.ID, &section.Title, &section.Slug, &section.Content,
-			&section.CodeExample, &section.Category, &section.Order,
-			&section.Searchable, &section.CreatedAt, &section.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		sections = append(sections, section)
-	}
-
-	return sections, rows.Err()
+func (s *DocsService) SearchSections(ctx context.Context, query string) ([]DocSection, error) {
+	return s.db.DocSections().Search(ctx, query, 50)
 }
 
-func (s *DocsService) GetSectionsByCategory(category string) ([]DocSection, error) {
-	query := `
-	SELECT id, title, slug, content, COALESCE(code_example, ''), category, 
-	       order_num, COALESCE(searchable, ''), created_at, updated_at
-	FROM doc_sections 
-	WHERE category = ?
-	ORDER BY order_num, title
-	`
-
-	rows, err := s.db.GetConnection().Query(query, category)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var sections []DocSection
-	for rows.Next() {
-		var section DocSection
-		err := rows.Scan(
-			&section.ID, &section.Title, &section.Slug, &section.Content,
-			&section.CodeExample, &section.Category, &section.Order,
-			&section.Searchable, &section.CreatedAt, &section.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		sections = append(sections, section)
-	}
+func (s *DocsService) GetSectionsByCategory(ctx context.Context, category string) ([]DocSection, error) {
+	return s.db.DocSections().ByCategory(ctx, category)
+}
 
-	return sections, rows.Err()
+// SeedData fills an empty doc_sections table in one transaction, so a
+// failed seed leaves nothing behind to be mistaken for complete data.
+func (s *DocsService) SeedData(ctx context.Context) error {
+	return s.db.WithTx(ctx, func(tx database.Tx) error {
+		return seed(ctx, tx)
+	})
 }
 
-func (s *DocsService) SeedData() error {
+func seed(ctx context.Context, tx database.Tx) error {
 	// Check if data already exists
-	var count int
-	err := s.db.GetConnection().QueryRow("SELECT COUNT(*) FROM doc_sections").Scan(&count)
+	count, err := tx.DocSections().Count(ctx)
 	if err != nil {
 		return err
 	}
@@ -1244,14 +1119,8 @@ func RateLimitMiddleware(requestsPerMinute int) func(http.Handler) http.Handler
 	}
 
 	for _, section := range sections {
-		_, err := s.db.GetConnection().Exec(`
-			INSERT INTO doc_sections (title, slug, content, code_example, category, order_num, searchable)
-			VALUES (?, ?, ?, ?, ?, ?, ?)
-		`, section.Title, section.Slug, section.Content, section.CodeExample,
-			section.Category, section.Order, section.Searchable)
-
-		if err != nil {
-			return fmt.Errorf("failed to insert section %s: %w", section.Title, err)
+		if err := tx.DocSections().Create(ctx, section); err != nil {
+			return err
 		}
 	}
 
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS doc_sections (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	title TEXT NOT NULL,
+	slug TEXT UNIQUE NOT NULL,
+	content TEXT NOT NULL,
+	code_example TEXT,
+	category TEXT NOT NULL,
+	order_num INTEGER DEFAULT 0,
+	searchable TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_doc_sections_slug ON doc_sections(slug);
+CREATE INDEX IF NOT EXISTS idx_doc_sections_category ON doc_sections(category);
+CREATE INDEX IF NOT EXISTS idx_doc_sections_order ON doc_sections(order_num);
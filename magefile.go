@@ -28,10 +28,16 @@ const (
 	apiPlaygroundDir = "./apps/web/api-playground"
 	goSocialDir      = "./apps/web/go-social"
 	goHyperDocsDir   = "./apps/web/gohyperdocs"
+	authDir          = "./pkg/auth"
 	componentsDir    = "./pkg/components"
+	configDir        = "./pkg/config"
 	databaseDir      = "./pkg/database"
+	healthDir        = "./pkg/health"
+	metricsDir       = "./pkg/metrics"
 	middlewareDir    = "./pkg/middleware"
+	serverDir        = "./pkg/server"
 	stylesDir        = "./pkg/styles"
+	tracingDir       = "./pkg/tracing"
 	utilsDir         = "./pkg/utils"
 )
 
@@ -90,6 +96,9 @@ type Tools mg.Namespace
 // Production namespace
 type Prod mg.Namespace
 
+// Database namespace
+type DB mg.Namespace
+
 // Help displays available targets
 func Help() {
 	colorPrint(colorCyan, "Go Standard Library Web Stack - The Ultimate Hypermedia-Driven Web Stack")
@@ -120,6 +129,10 @@ func Help() {
 	fmt.Println("  mage prod:caddy      Start Caddy reverse proxy")
 	fmt.Println("  mage prod:release    Create production release")
 	fmt.Println()
+	colorPrint(colorBlue, "Database Commands:")
+	fmt.Println("  mage db:backup       Back up ./data/app.db (DATA_DIR, BACKUP_DIR, BACKUP_COMPRESS, BACKUP_KEEP)")
+	fmt.Println("  mage db:restore      Restore the newest backup, or BACKUP_FILE, after an integrity check")
+	fmt.Println()
 	colorPrint(colorBlue, "Maintenance Commands:")
 	fmt.Println("  mage clean           Clean build artifacts")
 	fmt.Println("  mage format          Format all Go code and tidy modules")
@@ -277,10 +290,16 @@ func (Dev) Deps() error {
 		apiPlaygroundDir,
 		goSocialDir,
 		goHyperDocsDir,
+		authDir,
 		componentsDir,
+		configDir,
 		databaseDir,
+		healthDir,
+		metricsDir,
 		middlewareDir,
+		serverDir,
 		stylesDir,
+		tracingDir,
 		utilsDir,
 	}
 
@@ -305,10 +324,16 @@ func (Dev) Tidy() error {
 		apiPlaygroundDir,
 		goSocialDir,
 		goHyperDocsDir,
+		authDir,
 		componentsDir,
+		configDir,
 		databaseDir,
+		healthDir,
+		metricsDir,
 		middlewareDir,
+		serverDir,
 		stylesDir,
+		tracingDir,
 		utilsDir,
 	}
 
@@ -345,10 +370,16 @@ func (Dev) Lint() error {
 		apiPlaygroundDir,
 		goSocialDir,
 		goHyperDocsDir,
+		authDir,
 		componentsDir,
+		configDir,
 		databaseDir,
+		healthDir,
+		metricsDir,
 		middlewareDir,
+		serverDir,
 		stylesDir,
+		tracingDir,
 		utilsDir,
 	}
 
@@ -576,10 +607,16 @@ func (Test) All() error {
 		apiPlaygroundDir,
 		goSocialDir,
 		goHyperDocsDir,
+		authDir,
 		componentsDir,
+		configDir,
 		databaseDir,
+		healthDir,
+		metricsDir,
 		middlewareDir,
+		serverDir,
 		stylesDir,
+		tracingDir,
 		utilsDir,
 	}
 
@@ -677,10 +714,16 @@ func (Tools) Upgrade() error {
 		apiPlaygroundDir,
 		goSocialDir,
 		goHyperDocsDir,
+		authDir,
 		componentsDir,
+		configDir,
 		databaseDir,
+		healthDir,
+		metricsDir,
 		middlewareDir,
+		serverDir,
 		stylesDir,
+		tracingDir,
 		utilsDir,
 	}
 
@@ -751,6 +794,55 @@ func (Prod) Release() error {
 	return nil
 }
 
+// dbctlArgs builds the dbctl command line from the DATA_DIR and BACKUP_*
+// variables the apps read.
+func dbctlArgs(command string) []string {
+	args := []string{"run", "./pkg/database/cmd/dbctl", command}
+	if dir := os.Getenv("DATA_DIR"); dir != "" {
+		args = append(args, "-data", dir)
+	}
+	if dir := os.Getenv("BACKUP_DIR"); dir != "" {
+		args = append(args, "-dir", dir)
+	}
+	return args
+}
+
+// Backup writes a hot backup of the database with VACUUM INTO
+func (DB) Backup() error {
+	printSection("Backing up database...")
+
+	args := dbctlArgs("backup")
+	if os.Getenv("BACKUP_COMPRESS") == "true" {
+		args = append(args, "-gzip")
+	}
+	if keep := os.Getenv("BACKUP_KEEP"); keep != "" {
+		args = append(args, "-keep", keep)
+	}
+	if err := sh.RunV("go", args...); err != nil {
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+
+	printSuccess("Database backed up")
+	return nil
+}
+
+// Restore replaces the database with BACKUP_FILE or the newest backup
+func (DB) Restore() error {
+	printSection("Restoring database...")
+	printWarning("Stop the apps first; the current database is kept as app.db.before-restore-<time>")
+
+	args := dbctlArgs("restore")
+	if file := os.Getenv("BACKUP_FILE"); file != "" {
+		args = append(args, file)
+	}
+	if err := sh.RunV("go", args...); err != nil {
+		return fmt.Errorf("failed to restore database: %w", err)
+	}
+
+	printSuccess("Database restored")
+	return nil
+}
+
 // Format formats all Go code in the monorepo
 func Format() error {
 	printSection("Formatting all Go code in monorepo...")
@@ -765,10 +857,16 @@ func Format() error {
 		apiPlaygroundDir,
 		goSocialDir,
 		goHyperDocsDir,
+		authDir,
 		componentsDir,
+		configDir,
 		databaseDir,
+		healthDir,
+		metricsDir,
 		middlewareDir,
+		serverDir,
 		stylesDir,
+		tracingDir,
 		utilsDir,
 	}
 
@@ -0,0 +1,33 @@
+module github.com/dunamismax/go-stdlib/pkg/auth
+
+go 1.24
+
+require (
+	github.com/dunamismax/go-stdlib/pkg/database v0.0.0
+	golang.org/x/crypto v0.40.0
+)
+
+require (
+	github.com/dunamismax/go-stdlib/pkg/middleware v0.0.0 // indirect
+	github.com/dunamismax/go-stdlib/pkg/tracing v0.0.0 // indirect
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/hashicorp/golang-lru/v2 v2.0.7 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	golang.org/x/sys v0.34.0 // indirect
+	modernc.org/gc/v3 v3.0.0-20240107210532-573471604cb6 // indirect
+	modernc.org/libc v1.55.3 // indirect
+	modernc.org/mathutil v1.6.0 // indirect
+	modernc.org/memory v1.8.0 // indirect
+	modernc.org/sqlite v1.34.4 // indirect
+	modernc.org/strutil v1.2.0 // indirect
+	modernc.org/token v1.1.0 // indirect
+)
+
+replace github.com/dunamismax/go-stdlib/pkg/database => ../database
+
+replace github.com/dunamismax/go-stdlib/pkg/middleware => ../middleware
+
+replace github.com/dunamismax/go-stdlib/pkg/tracing => ../tracing
@@ -0,0 +1,278 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrUnknownHashFormat is returned when no hasher recognizes a stored hash.
+	ErrUnknownHashFormat = errors.New("unknown password hash format")
+
+	// ErrInvalidHash is returned when a stored hash is recognized but malformed.
+	ErrInvalidHash = errors.New("invalid password hash")
+
+	// ErrHashNotSupported is returned by verify-only hashers asked to create a hash.
+	ErrHashNotSupported = errors.New("hasher does not create new hashes")
+)
+
+// PasswordHasher creates and verifies self-describing password hashes.
+type PasswordHasher interface {
+	// Hash returns an encoded hash that records its algorithm and parameters.
+	Hash(password string) (string, error)
+	// Verify reports whether password matches encoded in constant time.
+	Verify(password, encoded string) (bool, error)
+	// Identifies reports whether encoded was produced by this hasher's algorithm.
+	Identifies(encoded string) bool
+	// NeedsRehash reports whether encoded uses weaker parameters than the hasher's.
+	NeedsRehash(encoded string) bool
+}
+
+// DefaultPasswordHasher hashes with argon2id and still verifies bcrypt and
+// legacy unsalted SHA-256 hashes so they can be upgraded on login.
+func DefaultPasswordHasher() *MultiHasher {
+	return NewMultiHasher(NewArgon2idHasher(), NewBcryptHasher(), LegacySHA256Hasher{})
+}
+
+// MultiHasher hashes with a primary hasher and verifies with whichever
+// hasher identifies the stored format.
+type MultiHasher struct {
+	primary PasswordHasher
+	hashers []PasswordHasher
+}
+
+// NewMultiHasher returns a MultiHasher that hashes with primary and also
+// accepts hashes produced by any of the fallbacks.
+func NewMultiHasher(primary PasswordHasher, fallbacks ...PasswordHasher) *MultiHasher {
+	return &MultiHasher{
+		primary: primary,
+		hashers: append([]PasswordHasher{primary}, fallbacks...),
+	}
+}
+
+func (m *MultiHasher) Hash(password string) (string, error) {
+	return m.primary.Hash(password)
+}
+
+func (m *MultiHasher) Verify(password, encoded string) (bool, error) {
+	h := m.find(encoded)
+	if h == nil {
+		return false, ErrUnknownHashFormat
+	}
+	return h.Verify(password, encoded)
+}
+
+func (m *MultiHasher) Identifies(encoded string) bool {
+	return m.find(encoded) != nil
+}
+
+// NeedsRehash is true when encoded was not produced by the primary hasher or
+// uses weaker parameters than it is configured with.
+func (m *MultiHasher) NeedsRehash(encoded string) bool {
+	if !m.primary.Identifies(encoded) {
+		return true
+	}
+	return m.primary.NeedsRehash(encoded)
+}
+
+func (m *MultiHasher) find(encoded string) PasswordHasher {
+	for _, h := range m.hashers {
+		if h.Identifies(encoded) {
+			return h
+		}
+	}
+	return nil
+}
+
+// Argon2idHasher produces PHC-format argon2id hashes:
+// $argon2id$v=19$m=<memory>,t=<time>,p=<threads>$<salt>$<hash>
+type Argon2idHasher struct {
+	Time    uint32
+	Memory  uint32 // KiB
+	Threads uint8
+	KeyLen  uint32
+	SaltLen uint32
+}
+
+// NewArgon2idHasher returns an argon2id hasher using the OWASP recommended
+// minimum of 19 MiB memory and two iterations.
+func NewArgon2idHasher() *Argon2idHasher {
+	return &Argon2idHasher{
+		Time:    2,
+		Memory:  19 * 1024,
+		Threads: 1,
+		KeyLen:  32,
+		SaltLen: 16,
+	}
+}
+
+// maxArgon2Memory caps the memory cost, in KiB, a stored hash may ask for,
+// so a tampered hash cannot make Verify allocate without bound.
+const maxArgon2Memory = 1 << 20 // 1 GiB
+
+type argon2Params struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Time, h.Memory, h.Threads, h.KeyLen)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Time, h.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *Argon2idHasher) Verify(password, encoded string) (bool, error) {
+	p, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	key := argon2.IDKey([]byte(password), p.salt, p.time, p.memory, p.threads, uint32(len(p.key)))
+	return subtle.ConstantTimeCompare(key, p.key) == 1, nil
+}
+
+func (h *Argon2idHasher) Identifies(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+func (h *Argon2idHasher) NeedsRehash(encoded string) bool {
+	p, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+
+	return p.time < h.Time ||
+		p.memory < h.Memory ||
+		p.threads < h.Threads ||
+		uint32(len(p.key)) < h.KeyLen ||
+		uint32(len(p.salt)) < h.SaltLen
+}
+
+func decodeArgon2id(encoded string) (*argon2Params, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, hash
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return nil, ErrInvalidHash
+	}
+	if version != argon2.Version {
+		return nil, fmt.Errorf("%w: unsupported argon2 version %d", ErrInvalidHash, version)
+	}
+
+	var p argon2Params
+	var threads uint32
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.time, &threads); err != nil {
+		return nil, ErrInvalidHash
+	}
+	// argon2.IDKey panics on zero time or threads and needs 8 KiB per lane
+	if p.time < 1 || threads < 1 || threads > 255 ||
+		p.memory < 8*threads || p.memory > maxArgon2Memory {
+		return nil, fmt.Errorf("%w: argon2 parameters out of range", ErrInvalidHash)
+	}
+	p.threads = uint8(threads)
+
+	var err error
+	if p.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, ErrInvalidHash
+	}
+	if p.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(p.key) == 0 {
+		return nil, ErrInvalidHash
+	}
+
+	return &p, nil
+}
+
+// BcryptHasher produces modular-crypt bcrypt hashes ($2a$<cost>$...).
+type BcryptHasher struct {
+	Cost int
+}
+
+// NewBcryptHasher returns a bcrypt hasher using cost 12.
+func NewBcryptHasher() *BcryptHasher {
+	return &BcryptHasher{Cost: 12}
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+func (h *BcryptHasher) Verify(password, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrInvalidHash, err)
+	}
+	return true, nil
+}
+
+func (h *BcryptHasher) Identifies(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") ||
+		strings.HasPrefix(encoded, "$2b$") ||
+		strings.HasPrefix(encoded, "$2y$")
+}
+
+func (h *BcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return true
+	}
+	return cost < h.Cost
+}
+
+// LegacySHA256Hasher verifies the unsalted hex SHA-256 hashes written by
+// earlier versions of go-social. It never creates new hashes and always
+// reports that a rehash is needed.
+type LegacySHA256Hasher struct{}
+
+func (LegacySHA256Hasher) Hash(string) (string, error) {
+	return "", ErrHashNotSupported
+}
+
+func (LegacySHA256Hasher) Verify(password, encoded string) (bool, error) {
+	sum := sha256.Sum256([]byte(password))
+	expected := hex.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(encoded))) == 1, nil
+}
+
+func (LegacySHA256Hasher) Identifies(encoded string) bool {
+	if len(encoded) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(encoded)
+	return err == nil
+}
+
+func (LegacySHA256Hasher) NeedsRehash(string) bool {
+	return true
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestPasswordHashers(t *testing.T) {
+	tests := []struct {
+		name   string
+		hasher PasswordHasher
+	}{
+		{"argon2id", &Argon2idHasher{Time: 1, Memory: 1024, Threads: 1, KeyLen: 32, SaltLen: 16}},
+		{"bcrypt", &BcryptHasher{Cost: 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := tt.hasher.Hash("correct horse battery staple")
+			if err != nil {
+				t.Fatalf("Hash() error = %v", err)
+			}
+
+			if !tt.hasher.Identifies(encoded) {
+				t.Errorf("Identifies(%q) = false, want true", encoded)
+			}
+
+			if ok, err := tt.hasher.Verify("correct horse battery staple", encoded); err != nil || !ok {
+				t.Errorf("Verify() with correct password = %v, %v, want true, nil", ok, err)
+			}
+
+			if ok, _ := tt.hasher.Verify("wrong password", encoded); ok {
+				t.Errorf("Verify() with wrong password = true, want false")
+			}
+
+			if tt.hasher.NeedsRehash(encoded) {
+				t.Errorf("NeedsRehash() for fresh hash = true, want false")
+			}
+		})
+	}
+}
+
+func TestMultiHasherUpgradesLegacyHashes(t *testing.T) {
+	weak := &Argon2idHasher{Time: 1, Memory: 1024, Threads: 1, KeyLen: 32, SaltLen: 16}
+	strong := &Argon2idHasher{Time: 2, Memory: 2048, Threads: 1, KeyLen: 32, SaltLen: 16}
+	hasher := NewMultiHasher(strong, &BcryptHasher{Cost: 4}, LegacySHA256Hasher{})
+
+	legacy := fmt.Sprintf("%x", sha256.Sum256([]byte("password123")))
+	weakHash, _ := weak.Hash("password123")
+	bcryptHash, _ := (&BcryptHasher{Cost: 4}).Hash("password123")
+	strongHash, _ := strong.Hash("password123")
+
+	tests := []struct {
+		name        string
+		encoded     string
+		needsRehash bool
+	}{
+		{"legacy sha256", legacy, true},
+		{"weaker argon2id", weakHash, true},
+		{"bcrypt", bcryptHash, true},
+		{"current argon2id", strongHash, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ok, err := hasher.Verify("password123", tt.encoded); err != nil || !ok {
+				t.Errorf("Verify() = %v, %v, want true, nil", ok, err)
+			}
+			if got := hasher.NeedsRehash(tt.encoded); got != tt.needsRehash {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.needsRehash)
+			}
+		})
+	}
+
+	if _, err := hasher.Verify("password123", "plaintext"); err != ErrUnknownHashFormat {
+		t.Errorf("Verify() unknown format error = %v, want %v", err, ErrUnknownHashFormat)
+	}
+}
+
+func TestDecodeArgon2idRejectsBadParameters(t *testing.T) {
+	const rest = "$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"
+
+	tests := []struct {
+		name   string
+		params string
+	}{
+		{"zero time", "m=1024,t=0,p=1"},
+		{"zero threads", "m=1024,t=1,p=0"},
+		{"too many threads", "m=4096,t=1,p=256"},
+		{"memory over cap", fmt.Sprintf("m=%d,t=1,p=1", maxArgon2Memory+1)},
+		{"memory under 8 KiB per thread", "m=15,t=1,p=2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeArgon2id("$argon2id$v=19$" + tt.params + rest)
+			if !errors.Is(err, ErrInvalidHash) {
+				t.Errorf("decodeArgon2id(%s) error = %v, want ErrInvalidHash", tt.params, err)
+			}
+		})
+	}
+
+	if _, err := decodeArgon2id("$argon2id$v=19$m=16,t=1,p=2" + rest); err != nil {
+		t.Errorf("decodeArgon2id(m=16,t=1,p=2) error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrSessionNotFound is returned when a session does not exist, has expired,
+// or has been revoked.
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session is a server-side login session. Revoking it invalidates the
+// cookie that references it immediately.
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     int       `json:"user_id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// SessionStore persists sessions.
+type SessionStore interface {
+	Create(ctx context.Context, session *Session) error
+	// Get returns the session or ErrSessionNotFound.
+	Get(ctx context.Context, id string) (*Session, error)
+	// Touch records activity and moves the expiry forward.
+	Touch(ctx context.Context, id string, lastSeenAt, expiresAt time.Time) error
+	Delete(ctx context.Context, id string) error
+	DeleteByUser(ctx context.Context, userID int) error
+	// ListByUser returns the user's sessions, most recently active first.
+	ListByUser(ctx context.Context, userID int) ([]*Session, error)
+	// DeleteExpired removes sessions that expired before the given time.
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// SessionManager issues and validates sessions with a sliding idle timeout
+// and an absolute maximum lifetime.
+type SessionManager struct {
+	store       SessionStore
+	idleTimeout time.Duration
+	maxLifetime time.Duration
+	// touchInterval limits how often activity is written back to the store.
+	touchInterval time.Duration
+	now           func() time.Time
+}
+
+// NewSessionManager returns a manager whose sessions expire after idleTimeout
+// without activity, and after maxLifetime regardless of activity.
+func NewSessionManager(store SessionStore, idleTimeout, maxLifetime time.Duration) *SessionManager {
+	return &SessionManager{
+		store:         store,
+		idleTimeout:   idleTimeout,
+		maxLifetime:   maxLifetime,
+		touchInterval: time.Minute,
+		now:           time.Now,
+	}
+}
+
+// MaxLifetime returns the absolute lifetime of a session.
+func (m *SessionManager) MaxLifetime() time.Duration {
+	return m.maxLifetime
+}
+
+// Create starts a new session for userID.
+func (m *SessionManager) Create(ctx context.Context, userID int, userAgent, ip string) (*Session, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now()
+	session := &Session{
+		ID:         id,
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IP:         ip,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  m.expiry(now, now),
+	}
+
+	if err := m.store.Create(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return session, nil
+}
+
+// Validate returns the active session for id and slides its expiry forward.
+func (m *SessionManager) Validate(ctx context.Context, id string) (*Session, error) {
+	session, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now()
+	if !now.Before(session.ExpiresAt) {
+		m.store.Delete(ctx, id)
+		return nil, ErrSessionNotFound
+	}
+
+	if now.Sub(session.LastSeenAt) >= m.touchInterval {
+		session.LastSeenAt = now
+		session.ExpiresAt = m.expiry(session.CreatedAt, now)
+		if err := m.store.Touch(ctx, id, session.LastSeenAt, session.ExpiresAt); err != nil {
+			slog.Warn("Failed to extend session", "error", err)
+		}
+	}
+
+	return session, nil
+}
+
+// Revoke ends a single session.
+func (m *SessionManager) Revoke(ctx context.Context, id string) error {
+	return m.store.Delete(ctx, id)
+}
+
+// RevokeAll ends every session belonging to userID ("log out all devices").
+func (m *SessionManager) RevokeAll(ctx context.Context, userID int) error {
+	return m.store.DeleteByUser(ctx, userID)
+}
+
+// List returns the user's unexpired sessions.
+func (m *SessionManager) List(ctx context.Context, userID int) ([]*Session, error) {
+	sessions, err := m.store.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now()
+	active := sessions[:0]
+	for _, s := range sessions {
+		if now.Before(s.ExpiresAt) {
+			active = append(active, s)
+		}
+	}
+
+	return active, nil
+}
+
+// StartCleanup deletes expired sessions every interval until the returned
+// stop function is called. Stop cancels a cleanup in progress and returns
+// once the goroutine has finished, so the store can be closed right after.
+// Calling it again does nothing.
+func (m *SessionManager) StartCleanup(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := make(chan struct{})
+
+	go func() {
+		defer close(finished)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				n, err := m.store.DeleteExpired(ctx, m.now())
+				if ctx.Err() != nil {
+					return
+				}
+				if err != nil {
+					slog.Error("Failed to clean up expired sessions", "error", err)
+				} else if n > 0 {
+					slog.Info("Cleaned up expired sessions", "count", n)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			<-finished
+		})
+	}
+}
+
+func (m *SessionManager) expiry(createdAt, now time.Time) time.Time {
+	idle := now.Add(m.idleTimeout)
+	absolute := createdAt.Add(m.maxLifetime)
+	if idle.After(absolute) {
+		return absolute
+	}
+	return idle
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate session ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dunamismax/go-stdlib/pkg/database"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// MigrationSet is the name the auth schema is tracked under in schema_migrations.
+const MigrationSet = "auth"
+
+// SQLiteSessionStore persists sessions in the shared SQLite database so they
+// survive restarts and can be revoked from any process.
+// Lookups go to the read pool and changes to the writer.
+type SQLiteSessionStore struct {
+	read  *sql.DB
+	write *sql.DB
+}
+
+// NewSQLiteSessionStore applies the auth migrations and returns a store backed by db.
+func NewSQLiteSessionStore(db *database.DB) (*SQLiteSessionStore, error) {
+	if err := db.RunMigrations(MigrationSet, migrationsFS, "migrations"); err != nil {
+		return nil, fmt.Errorf("failed to migrate sessions table: %w", err)
+	}
+	return &SQLiteSessionStore{read: db.ReadConnection(), write: db.GetConnection()}, nil
+}
+
+func (s *SQLiteSessionStore) Create(ctx context.Context, session *Session) error {
+	query := `INSERT INTO sessions (id, user_id, user_agent, ip, created_at, last_seen_at, expires_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := s.write.ExecContext(ctx, query,
+		session.ID, session.UserID, session.UserAgent, session.IP,
+		session.CreatedAt.Unix(), session.LastSeenAt.Unix(), session.ExpiresAt.Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert session: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteSessionStore) Get(ctx context.Context, id string) (*Session, error) {
+	query := `SELECT id, user_id, user_agent, ip, created_at, last_seen_at, expires_at
+			 FROM sessions WHERE id = ?`
+
+	session, err := scanSession(s.read.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return session, nil
+}
+
+func (s *SQLiteSessionStore) Touch(ctx context.Context, id string, lastSeenAt, expiresAt time.Time) error {
+	query := `UPDATE sessions SET last_seen_at = ?, expires_at = ? WHERE id = ?`
+
+	_, err := s.write.ExecContext(ctx, query, lastSeenAt.Unix(), expiresAt.Unix(), id)
+	if err != nil {
+		return fmt.Errorf("failed to touch session: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteSessionStore) Delete(ctx context.Context, id string) error {
+	_, err := s.write.ExecContext(ctx, `DELETE FROM sessions WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteSessionStore) DeleteByUser(ctx context.Context, userID int) error {
+	_, err := s.write.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = ?`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user sessions: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteSessionStore) ListByUser(ctx context.Context, userID int) ([]*Session, error) {
+	query := `SELECT id, user_id, user_agent, ip, created_at, last_seen_at, expires_at
+			 FROM sessions WHERE user_id = ? ORDER BY last_seen_at DESC`
+
+	rows, err := s.read.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func (s *SQLiteSessionStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.write.ExecContext(ctx, `DELETE FROM sessions WHERE expires_at <= ?`, before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSession(row rowScanner) (*Session, error) {
+	var session Session
+	var createdAt, lastSeenAt, expiresAt int64
+
+	err := row.Scan(
+		&session.ID, &session.UserID, &session.UserAgent, &session.IP,
+		&createdAt, &lastSeenAt, &expiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	session.CreatedAt = time.Unix(createdAt, 0)
+	session.LastSeenAt = time.Unix(lastSeenAt, 0)
+	session.ExpiresAt = time.Unix(expiresAt, 0)
+
+	return &session, nil
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dunamismax/go-stdlib/pkg/database"
+)
+
+func testSessionStores(t *testing.T) map[string]SessionStore {
+	db, err := database.NewDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	// Sessions reference users, so the tests' user ID 1 has to exist
+	if _, err := db.Users().Create(context.Background(), "gopher", "gopher@example.com", "", "hash"); err != nil {
+		t.Fatalf("Users().Create() error = %v", err)
+	}
+
+	sqliteStore, err := NewSQLiteSessionStore(db)
+	if err != nil {
+		t.Fatalf("NewSQLiteSessionStore() error = %v", err)
+	}
+
+	return map[string]SessionStore{
+		"memory": NewMemorySessionStore(),
+		"sqlite": sqliteStore,
+	}
+}
+
+func TestSessionManager(t *testing.T) {
+	ctx := context.Background()
+
+	for name, store := range testSessionStores(t) {
+		t.Run(name, func(t *testing.T) {
+			now := time.Unix(1_700_000_000, 0)
+			m := NewSessionManager(store, time.Hour, 24*time.Hour)
+			m.now = func() time.Time { return now }
+
+			first, err := m.Create(ctx, 1, "test-agent", "127.0.0.1")
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			second, _ := m.Create(ctx, 1, "other-agent", "127.0.0.2")
+
+			// Activity slides the idle expiry forward
+			now = now.Add(50 * time.Minute)
+			if _, err := m.Validate(ctx, first.ID); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			now = now.Add(50 * time.Minute)
+			if _, err := m.Validate(ctx, first.ID); err != nil {
+				t.Errorf("Validate() after sliding expiry error = %v, want nil", err)
+			}
+			if _, err := m.Validate(ctx, second.ID); !errors.Is(err, ErrSessionNotFound) {
+				t.Errorf("Validate() idle session error = %v, want %v", err, ErrSessionNotFound)
+			}
+
+			sessions, err := m.List(ctx, 1)
+			if err != nil || len(sessions) != 1 {
+				t.Errorf("List() = %d sessions, %v, want 1, nil", len(sessions), err)
+			}
+
+			if err := m.RevokeAll(ctx, 1); err != nil {
+				t.Fatalf("RevokeAll() error = %v", err)
+			}
+			if _, err := m.Validate(ctx, first.ID); !errors.Is(err, ErrSessionNotFound) {
+				t.Errorf("Validate() after RevokeAll() error = %v, want %v", err, ErrSessionNotFound)
+			}
+		})
+	}
+}
+
+func TestSessionStoreDeleteExpired(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1_700_000_000, 0)
+
+	for name, store := range testSessionStores(t) {
+		t.Run(name, func(t *testing.T) {
+			store.Create(ctx, &Session{ID: "expired", UserID: 1, CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(-time.Minute)})
+			store.Create(ctx, &Session{ID: "active", UserID: 1, CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Minute)})
+
+			n, err := store.DeleteExpired(ctx, now)
+			if err != nil || n != 1 {
+				t.Errorf("DeleteExpired() = %v, %v, want 1, nil", n, err)
+			}
+			if _, err := store.Get(ctx, "active"); err != nil {
+				t.Errorf("Get(active) error = %v, want nil", err)
+			}
+		})
+	}
+}
+
+// blockingStore holds DeleteExpired until its context is cancelled.
+type blockingStore struct {
+	SessionStore
+	started chan struct{}
+	done    chan struct{}
+}
+
+func (s *blockingStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	select {
+	case s.started <- struct{}{}:
+	default:
+	}
+	<-ctx.Done()
+	close(s.done)
+	return 0, ctx.Err()
+}
+
+func TestStartCleanupStopWaits(t *testing.T) {
+	store := &blockingStore{
+		SessionStore: NewMemorySessionStore(),
+		started:      make(chan struct{}, 1),
+		done:         make(chan struct{}),
+	}
+	m := NewSessionManager(store, time.Hour, 24*time.Hour)
+
+	stop := m.StartCleanup(time.Millisecond)
+	<-store.started
+	stop()
+
+	select {
+	case <-store.done:
+	default:
+		t.Fatal("stop() returned while DeleteExpired was still running")
+	}
+	stop() // a second call must not panic
+}
@@ -0,0 +1,412 @@
+// Package config loads a typed configuration struct from, in increasing order
+// of precedence: the values already in the struct (the defaults), an optional
+// JSON or TOML file, environment variables and command-line flags.
+//
+// Fields take part when they carry a config tag naming their key:
+//
+//	type Config struct {
+//		Addr          string        `config:"addr" usage:"listen address"`
+//		ReadTimeout   time.Duration `config:"read_timeout"`
+//		SessionSecret string        `config:"session_secret" secret:"true" required:"true"`
+//		Server        server.Config `config:"server"`
+//	}
+//
+// A key maps to the environment variable SESSION_SECRET (upper-cased, with
+// the optional prefix) and the flag -session-secret. Nested structs join keys
+// with dots in files and flags and underscores in the environment, so
+// server.addr is SERVER_ADDR and -server.addr. Any variable may instead be
+// read from a file named by the same variable with a _FILE suffix, which is
+// how container secrets are usually mounted.
+package config
+
+import (
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options configures Load. With the zero value Load skips flags and reads the
+// unprefixed environment, plus any file CONFIG_FILE names.
+type Options struct {
+	// Name is the program name shown in flag usage. Default os.Args[0].
+	Name string
+	// Args are the command-line arguments without the program name, usually
+	// os.Args[1:]. Nil skips flag parsing.
+	Args []string
+	// EnvPrefix is prepended to every environment variable, such as "APP_".
+	EnvPrefix string
+	// File is read when present. The -config flag and the CONFIG_FILE
+	// variable override it, and a file named by them must exist. Files
+	// ending in .toml are parsed as TOML and anything else as JSON.
+	File string
+	// LookupEnv default os.LookupEnv.
+	LookupEnv func(key string) (string, bool)
+	// Output receives flag usage and errors. Default os.Stderr.
+	Output io.Writer
+}
+
+// Validator is implemented by configuration structs with checks beyond
+// required fields. Load calls Validate after all sources are applied.
+type Validator interface {
+	Validate() error
+}
+
+// field is one configurable leaf of the struct.
+type field struct {
+	key      string
+	value    reflect.Value
+	usage    string
+	required bool
+	secret   bool
+}
+
+func (f field) env(prefix string) string {
+	return prefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(f.key))
+}
+
+func (f field) flag() string {
+	return strings.ReplaceAll(f.key, "_", "-")
+}
+
+// Load fills dst, a pointer to a struct, from the configured sources and
+// validates the result. It returns flag.ErrHelp when -h or -help is passed.
+func Load(dst any, opts Options) error {
+	if opts.Name == "" && len(os.Args) > 0 {
+		opts.Name = os.Args[0]
+	}
+	if opts.LookupEnv == nil {
+		opts.LookupEnv = os.LookupEnv
+	}
+	if opts.Output == nil {
+		opts.Output = os.Stderr
+	}
+
+	fields, err := collect(dst)
+	if err != nil {
+		return err
+	}
+
+	// Flags are parsed first to find -config but applied last
+	flags, file, err := parseFlags(fields, opts)
+	if err != nil {
+		return err
+	}
+
+	mustExist := file != ""
+	if file == "" {
+		if path, ok := opts.LookupEnv(opts.EnvPrefix + "CONFIG_FILE"); ok && path != "" {
+			file, mustExist = path, true
+		} else {
+			file = opts.File
+		}
+	}
+	if file != "" {
+		values, err := readFile(file)
+		switch {
+		case errors.Is(err, os.ErrNotExist) && !mustExist:
+		case err != nil:
+			return err
+		default:
+			for _, f := range fields {
+				if raw, ok := values[f.key]; ok {
+					if err := set(f.value, raw); err != nil {
+						return fmt.Errorf("invalid value for %s in %s: %w", f.key, file, err)
+					}
+				}
+			}
+		}
+	}
+
+	for _, f := range fields {
+		name := f.env(opts.EnvPrefix)
+		raw, ok := opts.LookupEnv(name)
+		if path, fromFile := opts.LookupEnv(name + "_FILE"); fromFile && path != "" {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read %s_FILE: %w", name, err)
+			}
+			raw, ok = strings.TrimRight(string(data), "\r\n"), true
+		}
+		if !ok {
+			continue
+		}
+		if err := set(f.value, raw); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+	}
+
+	for _, f := range fields {
+		if raw, ok := flags[f.key]; ok {
+			if err := set(f.value, raw); err != nil {
+				return fmt.Errorf("invalid value for -%s: %w", f.flag(), err)
+			}
+		}
+	}
+
+	var missing []string
+	for _, f := range fields {
+		if f.required && f.value.IsZero() {
+			missing = append(missing, f.env(opts.EnvPrefix))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required configuration: %s", strings.Join(missing, ", "))
+	}
+
+	if v, ok := dst.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+	return nil
+}
+
+func collect(dst any) ([]field, error) {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("config: Load requires a pointer to a struct, got %T", dst)
+	}
+	var fields []field
+	if err := collectStruct(v.Elem(), "", &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+func collectStruct(v reflect.Value, prefix string, fields *[]field) error {
+	t := v.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		key, ok := sf.Tag.Lookup("config")
+		if !ok || key == "-" || !sf.IsExported() {
+			continue
+		}
+		key = prefix + key
+		fv := v.Field(i)
+
+		if fv.Kind() == reflect.Struct && !reflect.PointerTo(fv.Type()).Implements(textUnmarshalerType) {
+			if err := collectStruct(fv, key+".", fields); err != nil {
+				return err
+			}
+			continue
+		}
+		if !supported(fv) {
+			return fmt.Errorf("config: unsupported type %s for %s", fv.Type(), key)
+		}
+
+		*fields = append(*fields, field{
+			key:      key,
+			value:    fv,
+			usage:    sf.Tag.Get("usage"),
+			required: sf.Tag.Get("required") == "true",
+			secret:   sf.Tag.Get("secret") == "true",
+		})
+	}
+	return nil
+}
+
+func supported(v reflect.Value) bool {
+	if reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return v.Type().Elem().Kind() == reflect.String
+	}
+	return false
+}
+
+// set assigns raw to v. Raw is a string from the environment or a flag, or a
+// string or []string from a file. Strings are split on commas for slices.
+func set(v reflect.Value, raw any) error {
+	if v.Kind() == reflect.Slice {
+		var items []string
+		switch raw := raw.(type) {
+		case []string:
+			items = raw
+		case string:
+			for _, item := range strings.Split(raw, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+		}
+		v.Set(reflect.ValueOf(items).Convert(v.Type()))
+		return nil
+	}
+
+	s, ok := raw.(string)
+	if !ok {
+		return fmt.Errorf("expected a single value, got a list")
+	}
+
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == reflect.TypeFor[time.Duration]() {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	}
+	return nil
+}
+
+// format renders v the way set parses it.
+func format(v reflect.Value) string {
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, _ := m.MarshalText()
+		return string(text)
+	}
+	if v.Kind() == reflect.Slice {
+		return strings.Join(v.Convert(reflect.TypeFor[[]string]()).Interface().([]string), ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// flagValue collects a flag's raw value for later use; Load applies flags
+// after the file and environment so they take precedence.
+type flagValue struct {
+	key    string
+	isBool bool
+	def    string
+	set    map[string]any
+}
+
+func (f *flagValue) String() string { return f.def }
+
+func (f *flagValue) Set(s string) error {
+	f.set[f.key] = s
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool { return f.isBool }
+
+func parseFlags(fields []field, opts Options) (map[string]any, string, error) {
+	values := make(map[string]any)
+	if opts.Args == nil {
+		return values, "", nil
+	}
+
+	fs := flag.NewFlagSet(opts.Name, flag.ContinueOnError)
+	fs.SetOutput(opts.Output)
+	file := fs.String("config", "", "path to a JSON or TOML configuration file (env "+opts.EnvPrefix+"CONFIG_FILE)")
+	for _, f := range fields {
+		// Hide secrets and zero values, as the flag package does
+		var def string
+		if !f.secret && !f.value.IsZero() {
+			def = format(f.value)
+		}
+		usage := f.usage
+		if usage != "" {
+			usage += " "
+		}
+		usage += "(env " + f.env(opts.EnvPrefix) + ")"
+		fs.Var(&flagValue{key: f.key, isBool: f.value.Kind() == reflect.Bool, def: def, set: values}, f.flag(), usage)
+	}
+
+	if err := fs.Parse(opts.Args); err != nil {
+		return nil, "", err
+	}
+	if fs.NArg() > 0 {
+		return nil, "", fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	return values, *file, nil
+}
+
+// Redacted returns the effective configuration of src, a struct or pointer
+// to one, keyed like a configuration file. Secret values that are set are
+// replaced by "[REDACTED]", so the result is safe to log.
+func Redacted(src any) map[string]string {
+	fields, err := collectValue(src)
+	if err != nil {
+		return map[string]string{"error": err.Error()}
+	}
+	out := make(map[string]string, len(fields))
+	for _, f := range fields {
+		out[f.key] = f.redacted()
+	}
+	return out
+}
+
+// Fprint writes the effective configuration of src to w as "key = value"
+// lines in field order, with secrets redacted.
+func Fprint(w io.Writer, src any) error {
+	fields, err := collectValue(src)
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		if _, err := fmt.Fprintf(w, "%s = %s\n", f.key, f.redacted()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f field) redacted() string {
+	if f.secret && !f.value.IsZero() {
+		return "[REDACTED]"
+	}
+	return format(f.value)
+}
+
+// collectValue is collect for a struct passed by value or pointer. It works
+// on a copy, so the caller's struct is never touched.
+func collectValue(src any) ([]field, error) {
+	v := reflect.ValueOf(src)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("config: expected a struct, got %T", src)
+	}
+	c := reflect.New(v.Type())
+	c.Elem().Set(v)
+	return collect(c.Interface())
+}
@@ -0,0 +1,255 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testServer struct {
+	Addr        string        `config:"addr"`
+	ReadTimeout time.Duration `config:"read_timeout"`
+	Handler     any
+}
+
+type testConfig struct {
+	Server   testServer `config:"server"`
+	DataDir  string     `config:"data_dir" usage:"database directory"`
+	HTTPS    bool       `config:"https"`
+	Workers  int        `config:"workers"`
+	Origins  []string   `config:"origins"`
+	Secret   string     `config:"secret" secret:"true" required:"true"`
+	internal string
+}
+
+func (c *testConfig) Validate() error {
+	if c.Workers < 0 {
+		return errors.New("workers must not be negative")
+	}
+	return nil
+}
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeFile(t, "app.toml", `
+data_dir = "/srv/file"   # overridden by the environment
+workers = 2
+origins = ["https://a.example", 'https://b.example']
+
+[server]
+addr = ":9000"
+read_timeout = "3s"
+`)
+
+	cfg := testConfig{Server: testServer{Addr: ":8080", ReadTimeout: time.Second}, DataDir: "./data"}
+	err := Load(&cfg, Options{
+		Args: []string{"-workers", "4", "-https"},
+		File: file,
+		LookupEnv: env(map[string]string{
+			"APP_DATA_DIR": "/srv/env",
+			"APP_WORKERS":  "3",
+			"APP_SECRET":   "s3cret",
+		}),
+		EnvPrefix: "APP_",
+	})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := testConfig{
+		Server:  testServer{Addr: ":9000", ReadTimeout: 3 * time.Second},
+		DataDir: "/srv/env",
+		HTTPS:   true,
+		Workers: 4,
+		Origins: []string{"https://a.example", "https://b.example"},
+		Secret:  "s3cret",
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Load() = %+v, want %+v", cfg, want)
+	}
+}
+
+func TestLoadSources(t *testing.T) {
+	secretFile := writeFile(t, "secret", "from-file\n")
+	jsonFile := writeFile(t, "app.json", `{"server": {"addr": ":7000"}, "workers": 5, "origins": ["x", "y"], "https": true}`)
+
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		want    func(*testConfig) bool
+		wantErr string
+	}{
+		{
+			name: "secret from _FILE",
+			env:  map[string]string{"SECRET_FILE": secretFile},
+			want: func(c *testConfig) bool { return c.Secret == "from-file" },
+		},
+		{
+			name: "json file from flag",
+			args: []string{"-config", jsonFile, "-secret", "x"},
+			want: func(c *testConfig) bool {
+				return c.Server.Addr == ":7000" && c.Workers == 5 && c.HTTPS && len(c.Origins) == 2
+			},
+		},
+		{
+			name: "json file from CONFIG_FILE",
+			env:  map[string]string{"CONFIG_FILE": jsonFile, "SECRET": "x"},
+			want: func(c *testConfig) bool { return c.Server.Addr == ":7000" },
+		},
+		{
+			name: "comma-separated list and nested env",
+			env:  map[string]string{"ORIGINS": "a, b,,c", "SERVER_READ_TIMEOUT": "1m", "SECRET": "x"},
+			want: func(c *testConfig) bool {
+				return reflect.DeepEqual(c.Origins, []string{"a", "b", "c"}) && c.Server.ReadTimeout == time.Minute
+			},
+		},
+		{
+			name:    "missing required",
+			wantErr: "missing required configuration: SECRET",
+		},
+		{
+			name:    "invalid env value",
+			env:     map[string]string{"WORKERS": "many", "SECRET": "x"},
+			wantErr: "invalid value for WORKERS",
+		},
+		{
+			name:    "validator",
+			args:    []string{"-workers=-1", "-secret", "x"},
+			wantErr: "workers must not be negative",
+		},
+		{
+			name:    "missing explicit file",
+			args:    []string{"-config", filepath.Join(t.TempDir(), "missing.json")},
+			wantErr: "failed to read config file",
+		},
+		{
+			name:    "unknown flag",
+			args:    []string{"-nope"},
+			wantErr: "flag provided but not defined",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg testConfig
+			err := Load(&cfg, Options{Args: tt.args, LookupEnv: env(tt.env), Output: io.Discard})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Load() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !tt.want(&cfg) {
+				t.Errorf("Load() = %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestLoadHelp(t *testing.T) {
+	var out bytes.Buffer
+	cfg := testConfig{DataDir: "./data", Secret: "default"}
+	err := Load(&cfg, Options{Args: []string{"-h"}, Output: &out, LookupEnv: env(nil)})
+	if !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("Load(-h) error = %v, want flag.ErrHelp", err)
+	}
+
+	usage := out.String()
+	for _, want := range []string{"-data-dir", "database directory (env DATA_DIR)", "(default ./data)", "-server.read-timeout"} {
+		if !strings.Contains(usage, want) {
+			t.Errorf("usage missing %q:\n%s", want, usage)
+		}
+	}
+	if strings.Contains(usage, "(default default)") || strings.Contains(usage, "(default 0)") {
+		t.Errorf("usage shows a secret or zero default:\n%s", usage)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := testConfig{Server: testServer{Addr: ":8080"}, Origins: []string{"a", "b"}, Secret: "s3cret"}
+
+	got := Redacted(cfg)
+	if got["secret"] != "[REDACTED]" || got["server.addr"] != ":8080" || got["origins"] != "a,b" {
+		t.Errorf("Redacted() = %v", got)
+	}
+	if _, ok := got["server.Handler"]; ok {
+		t.Errorf("Redacted() includes untagged fields: %v", got)
+	}
+	if Redacted(testConfig{})["secret"] != "" {
+		t.Errorf("Redacted() hides an empty secret, want it shown as unset")
+	}
+
+	var out bytes.Buffer
+	if err := Fprint(&out, &cfg); err != nil {
+		t.Fatalf("Fprint() error = %v", err)
+	}
+	if want := "server.addr = :8080\nserver.read_timeout = 0s\n"; !strings.HasPrefix(out.String(), want) {
+		t.Errorf("Fprint() = %q, want prefix %q", out.String(), want)
+	}
+	if strings.Contains(out.String(), "s3cret") {
+		t.Errorf("Fprint() leaked the secret: %q", out.String())
+	}
+}
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]any
+		wantErr bool
+	}{
+		{
+			name:  "scalars and tables",
+			input: "a = 1_000\nb = \"x # not a comment\"\n[t.u]\nc = true # comment\n'd-e' = 1.5",
+			want:  map[string]any{"a": "1000", "b": "x # not a comment", "t.u.c": "true", "t.u.d-e": "1.5"},
+		},
+		{
+			name:  "escapes and arrays",
+			input: `s = "tab\there"` + "\n" + `l = [ "a", 2, ]` + "\n" + `e = []`,
+			want:  map[string]any{"s": "tab\there", "l": []string{"a", "2"}, "e": []string{}},
+		},
+		{name: "bare word", input: "a = hello", wantErr: true},
+		{name: "missing equals", input: "a", wantErr: true},
+		{name: "duplicate", input: "a = 1\na = 2", wantErr: true},
+		{name: "unterminated", input: `a = "x`, wantErr: true},
+		{name: "array of tables", input: "[[a]]", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTOML([]byte(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTOML() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTOML() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
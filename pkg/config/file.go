@@ -0,0 +1,240 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// readFile parses a JSON or TOML file into flattened keys such as
+// "server.addr", with each value a string or, for arrays, a []string.
+func readFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var values map[string]any
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		values, err = parseTOML(data)
+	} else {
+		values, err = parseJSON(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+func parseJSON(data []byte) (map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]any)
+	if err := flatten(doc, "", values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func flatten(doc map[string]any, prefix string, values map[string]any) error {
+	for key, v := range doc {
+		key = prefix + key
+		switch v := v.(type) {
+		case map[string]any:
+			if err := flatten(v, key+".", values); err != nil {
+				return err
+			}
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				switch item.(type) {
+				case map[string]any, []any:
+					return fmt.Errorf("%s: nested arrays are not supported", key)
+				}
+				items[i] = fmt.Sprint(item)
+			}
+			values[key] = items
+		case nil:
+			// null leaves the default in place
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+// parseTOML understands the subset of TOML that configuration files use:
+// comments, [table] headers, dotted keys, basic and literal strings,
+// integers, floats, booleans and single-line arrays of those.
+func parseTOML(data []byte) (map[string]any, error) {
+	values := make(map[string]any)
+	var table string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return nil, fmt.Errorf("line %d: invalid table header %q", n, line)
+			}
+			name, err := parseKey(line[1 : len(line)-1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			table = name + "."
+			continue
+		}
+
+		rawKey, rawValue, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", n)
+		}
+		key, err := parseKey(rawKey)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		value, err := parseTOMLValue(strings.TrimSpace(rawValue))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", n, key, err)
+		}
+		if _, dup := values[table+key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %s", n, table+key)
+		}
+		values[table+key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// stripComment drops a # comment that is not inside a string.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func parseKey(s string) (string, error) {
+	parts := strings.Split(strings.TrimSpace(s), ".")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if len(part) >= 2 && (part[0] == '"' || part[0] == '\'') && part[len(part)-1] == part[0] {
+			part = part[1 : len(part)-1]
+		} else if part == "" || strings.ContainsFunc(part, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-')
+		}) {
+			return "", fmt.Errorf("invalid key %q", s)
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, "."), nil
+}
+
+func parseTOMLValue(s string) (any, error) {
+	if !strings.HasPrefix(s, "[") {
+		v, rest, err := parseTOMLScalar(s)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(rest) != "" {
+			return nil, fmt.Errorf("unexpected %q after value", rest)
+		}
+		return v, nil
+	}
+
+	items := []string{}
+	rest := strings.TrimSpace(s[1:])
+	for {
+		if strings.HasPrefix(rest, "]") {
+			rest = rest[1:]
+			break
+		}
+		item, r, err := parseTOMLScalar(rest)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		rest = strings.TrimSpace(r)
+		if strings.HasPrefix(rest, ",") {
+			rest = strings.TrimSpace(rest[1:])
+		} else if !strings.HasPrefix(rest, "]") {
+			return nil, fmt.Errorf("unterminated array")
+		}
+	}
+	if strings.TrimSpace(rest) != "" {
+		return nil, fmt.Errorf("unexpected %q after array", rest)
+	}
+	return items, nil
+}
+
+// parseTOMLScalar parses one value at the start of s and returns the rest.
+func parseTOMLScalar(s string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(s, "'"):
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated string")
+		}
+		return s[1 : end+1], s[end+2:], nil
+	case strings.HasPrefix(s, `"`):
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				v, err := strconv.Unquote(s[:i+1])
+				if err != nil {
+					return "", "", fmt.Errorf("invalid string %s", s[:i+1])
+				}
+				return v, s[i+1:], nil
+			}
+		}
+		return "", "", fmt.Errorf("unterminated string")
+	}
+
+	end := strings.IndexAny(s, ",] \t")
+	if end < 0 {
+		end = len(s)
+	}
+	token := s[:end]
+	switch {
+	case token == "true" || token == "false":
+	case token == "":
+		return "", "", fmt.Errorf("missing value")
+	default:
+		// Underscores separate digits in TOML numbers
+		digits := strings.ReplaceAll(token, "_", "")
+		if _, err := strconv.ParseFloat(digits, 64); err != nil {
+			return "", "", fmt.Errorf("invalid value %q", token)
+		}
+		token = digits
+	}
+	return token, s[end:], nil
+}
@@ -0,0 +1,3 @@
+module github.com/dunamismax/go-stdlib/pkg/config
+
+go 1.24
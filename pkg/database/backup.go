@@ -0,0 +1,310 @@
+package database
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// BackupConfig configures Backup and StartBackups. The zero value keeps the
+// last seven uncompressed backups under the data directory and schedules
+// none.
+type BackupConfig struct {
+	// Dir receives the backups. Default "backups" inside the data directory.
+	Dir string `config:"dir" usage:"directory receiving database backups"`
+	// Compress gzips each backup.
+	Compress bool `config:"compress" usage:"gzip database backups"`
+	// Keep is how many backups survive pruning after each backup. Default
+	// 7; -1 keeps them all.
+	Keep int `config:"keep" usage:"number of database backups to keep, -1 for all"`
+	// Interval between scheduled backups. Zero disables StartBackups.
+	Interval time.Duration `config:"interval" usage:"how often to back up the database, 0 to disable"`
+}
+
+// backupTimeFormat sorts lexically in time order, which pruning relies on.
+const backupTimeFormat = "20060102T150405.000Z"
+
+// Backup writes a consistent copy of the live database to a timestamped
+// file in config.Dir and prunes old backups, returning the new file's path.
+// It uses VACUUM INTO on a connection of its own, so reads and writes carry
+// on while it runs and the copy is compacted.
+func (db *DB) Backup(ctx context.Context, config BackupConfig) (string, error) {
+	if config.Dir == "" {
+		config.Dir = filepath.Join(db.config.DataDir, "backups")
+	}
+	if config.Keep == 0 {
+		config.Keep = 7
+	}
+	if err := os.MkdirAll(config.Dir, 0750); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	name := "app-" + time.Now().UTC().Format(backupTimeFormat) + ".db"
+	path := filepath.Join(config.Dir, name)
+	tmp := path + ".tmp"
+	defer os.Remove(tmp)
+
+	// The pools cannot run it: the readers are query_only and the writer
+	// would hold up every write for the length of the copy
+	conn, err := sql.Open("sqlite", db.path+"?"+db.config.pragmas().Encode())
+	if err != nil {
+		return "", fmt.Errorf("failed to open database for backup: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "VACUUM INTO ?", tmp); err != nil {
+		return "", fmt.Errorf("failed to back up database: %w", err)
+	}
+
+	if config.Compress {
+		path += ".gz"
+		if err := gzipFile(tmp, path); err != nil {
+			os.Remove(path)
+			return "", err
+		}
+	} else if err := os.Rename(tmp, path); err != nil {
+		return "", fmt.Errorf("failed to move backup into place: %w", err)
+	}
+
+	if config.Keep > 0 {
+		if err := pruneBackups(config.Dir, config.Keep); err != nil {
+			return path, err
+		}
+	}
+	return path, nil
+}
+
+// StartBackups runs Backup every config.Interval until the returned stop
+// function is called. Stop cancels a backup in progress and returns once the
+// goroutine has finished, so the database can be closed right after. With a
+// zero Interval it does nothing.
+func (db *DB) StartBackups(config BackupConfig) (stop func()) {
+	if config.Interval <= 0 {
+		return func() {}
+	}
+
+	ticker := time.NewTicker(config.Interval)
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := make(chan struct{})
+
+	go func() {
+		defer close(finished)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				start := time.Now()
+				path, err := db.Backup(ctx, config)
+				if err != nil {
+					if ctx.Err() != nil {
+						return
+					}
+					slog.Error("Failed to back up database", "error", err)
+				} else {
+					slog.Info("Backed up database", "path", path, "duration", time.Since(start))
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-finished
+	}
+}
+
+// Backups lists the backups in dir, newest first.
+func Backups(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type().IsRegular() && strings.HasPrefix(name, "app-") &&
+			(strings.HasSuffix(name, ".db") || strings.HasSuffix(name, ".db.gz")) {
+			backups = append(backups, filepath.Join(dir, name))
+		}
+	}
+	slices.Sort(backups)
+	slices.Reverse(backups)
+	return backups, nil
+}
+
+func pruneBackups(dir string, keep int) error {
+	backups, err := Backups(dir)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, path := range backups[min(keep, len(backups)):] {
+		if err := os.Remove(path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to prune backups: %w", err)
+	}
+	return nil
+}
+
+// Restore replaces app.db in dataDir with the backup at backupPath, which
+// may be gzipped. The backup is copied next to app.db and must pass
+// PRAGMA integrity_check before the files are swapped; the replaced
+// database is kept as app.db.before-restore-<time>, so restoring again never
+// loses it. Stop every process using the database first.
+func Restore(ctx context.Context, backupPath, dataDir string) error {
+	if err := os.MkdirAll(dataDir, 0750); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+	dbPath := filepath.Join(dataDir, "app.db")
+	tmp := dbPath + ".restore"
+	defer os.Remove(tmp)
+
+	if err := copyBackup(backupPath, tmp); err != nil {
+		return err
+	}
+	if err := checkIntegrity(ctx, tmp); err != nil {
+		return fmt.Errorf("backup %s failed the integrity check: %w", backupPath, err)
+	}
+
+	if _, err := os.Stat(dbPath); err == nil {
+		// Fold the WAL into the old file so the kept copy is complete and
+		// the restored database does not pick up a stale log
+		if err := checkpoint(ctx, dbPath); err != nil {
+			return err
+		}
+		aside := dbPath + ".before-restore-" + time.Now().UTC().Format(backupTimeFormat)
+		if _, err := os.Lstat(aside); !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("refusing to overwrite %s", aside)
+		}
+		if err := os.Rename(dbPath, aside); err != nil {
+			return fmt.Errorf("failed to set aside the current database: %w", err)
+		}
+	}
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %w", dbPath+suffix, err)
+		}
+	}
+
+	if err := os.Rename(tmp, dbPath); err != nil {
+		return fmt.Errorf("failed to move restored database into place: %w", err)
+	}
+	return nil
+}
+
+func checkIntegrity(ctx context.Context, path string) error {
+	conn, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			return err
+		}
+		if result != "ok" {
+			problems = append(problems, result)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func checkpoint(ctx context.Context, path string) error {
+	conn, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("failed to open current database: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return fmt.Errorf("failed to checkpoint current database: %w", err)
+	}
+	return nil
+}
+
+func gzipFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create compressed backup: %w", err)
+	}
+	defer out.Close()
+
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		return fmt.Errorf("failed to compress backup: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress backup: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to write compressed backup: %w", err)
+	}
+	return nil
+}
+
+// copyBackup copies src to dst, decompressing it when it ends in .gz.
+func copyBackup(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer in.Close()
+
+	var r io.Reader = in
+	if strings.HasSuffix(src, ".gz") {
+		zr, err := gzip.NewReader(in)
+		if err != nil {
+			return fmt.Errorf("failed to decompress backup: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create restore file: %w", err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, r); err != nil {
+		return fmt.Errorf("failed to copy backup: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to write restore file: %w", err)
+	}
+	return nil
+}
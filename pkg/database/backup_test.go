@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		compress bool
+	}{
+		{"plain", false},
+		{"gzip", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			db, err := NewDB(dir)
+			if err != nil {
+				t.Fatalf("NewDB() error = %v", err)
+			}
+			if err := db.Migrate(); err != nil {
+				t.Fatalf("Migrate() error = %v", err)
+			}
+			if _, err := db.Users().Create(ctx, "ann", "ann@example.com", "", "h"); err != nil {
+				t.Fatal(err)
+			}
+
+			path, err := db.Backup(ctx, BackupConfig{Compress: tt.compress})
+			if err != nil {
+				t.Fatalf("Backup() error = %v", err)
+			}
+			if got := strings.HasSuffix(path, ".gz"); got != tt.compress {
+				t.Errorf("Backup() = %s, compressed = %v, want %v", path, got, tt.compress)
+			}
+
+			if _, err := db.Users().Create(ctx, "bob", "bob@example.com", "", "h"); err != nil {
+				t.Fatal(err)
+			}
+			db.Close()
+
+			if err := Restore(ctx, path, dir); err != nil {
+				t.Fatalf("Restore() error = %v", err)
+			}
+			// Restoring again keeps both replaced databases
+			time.Sleep(2 * time.Millisecond) // distinct timestamps
+			if err := Restore(ctx, path, dir); err != nil {
+				t.Fatalf("second Restore() error = %v", err)
+			}
+			if kept, _ := filepath.Glob(filepath.Join(dir, "app.db.before-restore-*")); len(kept) != 2 {
+				t.Errorf("replaced databases kept = %v, want 2", kept)
+			}
+
+			db, err = NewDB(dir)
+			if err != nil {
+				t.Fatalf("NewDB() error = %v", err)
+			}
+			defer db.Close()
+			if got := countUsers(t, db); got != 1 {
+				t.Errorf("users after restore = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestBackupPrunes(t *testing.T) {
+	db := newMigratedDB(t)
+	dir := t.TempDir()
+
+	for range 3 {
+		if _, err := db.Backup(context.Background(), BackupConfig{Dir: dir, Keep: 2}); err != nil {
+			t.Fatalf("Backup() error = %v", err)
+		}
+		time.Sleep(2 * time.Millisecond) // distinct timestamps
+	}
+
+	backups, err := Backups(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Errorf("Backups() = %v, want 2 files", backups)
+	}
+}
+
+func TestStartBackupsStopWaits(t *testing.T) {
+	db := newMigratedDB(t)
+	dir := t.TempDir()
+
+	stop := db.StartBackups(BackupConfig{Dir: dir, Keep: -1, Interval: time.Millisecond})
+	time.Sleep(20 * time.Millisecond)
+	stop()
+
+	// Nothing may still be writing once stop returns
+	before, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	after, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) != len(before) {
+		t.Errorf("backup directory changed after stop: %d files, then %d", len(before), len(after))
+	}
+	for _, entry := range after {
+		if strings.HasSuffix(entry.Name(), ".tmp") {
+			t.Errorf("temporary file %s left after stop", entry.Name())
+		}
+	}
+}
+
+func TestRestoreRejectsCorruptBackup(t *testing.T) {
+	dir := t.TempDir()
+	db := newMigratedDB(t)
+	path, err := db.Backup(context.Background(), BackupConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Clobber a page past the header so the file opens but fails the check
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 4096; i < min(len(data), 8192); i++ {
+		data[i] = 0xff
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err = Restore(context.Background(), path, dir)
+	if err == nil || !strings.Contains(err.Error(), "integrity check") {
+		t.Fatalf("Restore() error = %v, want integrity failure", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app.db")); !os.IsNotExist(err) {
+		t.Errorf("app.db exists after failed restore: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+// Command dbctl backs up and restores the SQLite database used by the apps.
+//
+//	dbctl backup [-data ./data] [-dir ./data/backups] [-gzip] [-keep 7]
+//	dbctl restore [-data ./data] [-dir ./data/backups] [backup file]
+//
+// restore without a file picks the newest backup in -dir. Stop the apps
+// before restoring.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/dunamismax/go-stdlib/pkg/database"
+)
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		log.Fatal("usage: dbctl backup|restore [flags]")
+	}
+	if err := run(os.Args[1], os.Args[2:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run carries out one command. It returns errors rather than exiting so its
+// deferred closes run first.
+func run(command string, args []string) error {
+	flags := flag.NewFlagSet("dbctl "+command, flag.ExitOnError)
+	dataDir := flags.String("data", "./data", "directory holding app.db")
+	dir := flags.String("dir", "", "backup directory (default <data>/backups)")
+	compress := flags.Bool("gzip", false, "gzip the backup")
+	keep := flags.Int("keep", 7, "backups to keep, -1 for all")
+	flags.Parse(args)
+
+	if *dir == "" {
+		*dir = filepath.Join(*dataDir, "backups")
+	}
+	ctx := context.Background()
+
+	switch command {
+	case "backup":
+		db, err := database.NewDB(*dataDir)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		defer db.Close()
+
+		path, err := db.Backup(ctx, database.BackupConfig{Dir: *dir, Compress: *compress, Keep: *keep})
+		if err != nil {
+			return fmt.Errorf("failed to back up database: %w", err)
+		}
+		fmt.Println(path)
+
+	case "restore":
+		path := flags.Arg(0)
+		if path == "" {
+			backups, err := database.Backups(*dir)
+			if err != nil {
+				return err
+			}
+			if len(backups) == 0 {
+				return fmt.Errorf("no backups in %s", *dir)
+			}
+			path = backups[0]
+		}
+
+		if err := database.Restore(ctx, path, *dataDir); err != nil {
+			return fmt.Errorf("failed to restore database: %w", err)
+		}
+		fmt.Printf("Restored %s into %s\n", path, filepath.Join(*dataDir, "app.db"))
+
+	default:
+		return fmt.Errorf("unknown command %q: want backup or restore", command)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"encoding/base64"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned by ParseCursor for a token it did not issue.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// timestampFormat is how CURRENT_TIMESTAMP stores created_at, so cursor
+// bounds compare with the column as text.
+const timestampFormat = "2006-01-02 15:04:05"
+
+// Cursor is a position in a newest-first listing of posts, ordered by
+// (created_at, id). A listing resumed from a cursor returns the posts after
+// it; the zero Cursor starts at the newest post.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int
+}
+
+// CursorAfter returns the cursor that resumes a listing after p.
+func CursorAfter(p Post) Cursor {
+	return Cursor{CreatedAt: p.CreatedAt, ID: p.ID}
+}
+
+func (c Cursor) IsZero() bool {
+	return c.ID == 0 && c.CreatedAt.IsZero()
+}
+
+// String encodes c as an opaque token for URLs; the zero Cursor is "".
+func (c Cursor) String() string {
+	if c.IsZero() {
+		return ""
+	}
+	raw := c.CreatedAt.UTC().Format(timestampFormat) + "|" + strconv.Itoa(c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseCursor decodes a token from Cursor.String. An empty token is the
+// zero Cursor.
+func ParseCursor(token string) (Cursor, error) {
+	if token == "" {
+		return Cursor{}, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return Cursor{}, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(timestampFormat, ts)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	n, err := strconv.Atoi(id)
+	if err != nil || n <= 0 {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return Cursor{CreatedAt: createdAt, ID: n}, nil
+}
+
+// bounds returns the (created_at, id) pair a listing must stay below. The
+// zero Cursor maps to a pair above every row, which keeps the queries'
+// row-value comparison, and so their index range scan, unconditional.
+func (c Cursor) bounds() (string, int) {
+	if c.IsZero() {
+		return "9999-12-31 23:59:59", math.MaxInt
+	}
+	return c.CreatedAt.UTC().Format(timestampFormat), c.ID
+}
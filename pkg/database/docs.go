@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DocSection is a page of gohyperdocs documentation. The doc_sections table
+// belongs to gohyperdocs' own migration set, not the core schema.
+type DocSection struct {
+	ID          int       `json:"id"`
+	Title       string    `json:"title"`
+	Slug        string    `json:"slug"`
+	Content     string    `json:"content"`
+	CodeExample string    `json:"code_example"`
+	Category    string    `json:"category"`
+	Order       int       `json:"order"`
+	Searchable  string    `json:"searchable"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type DocSections interface {
+	// All returns every section ordered by category, then position.
+	All(ctx context.Context) ([]DocSection, error)
+	GetBySlug(ctx context.Context, slug string) (*DocSection, error)
+	ByCategory(ctx context.Context, category string) ([]DocSection, error)
+	// Search matches title, content and keywords, title matches first.
+	Search(ctx context.Context, query string, limit int) ([]DocSection, error)
+	Count(ctx context.Context) (int, error)
+	Create(ctx context.Context, section DocSection) error
+}
+
+type docSections store
+
+const docSectionColumns = `id, title, slug, content, COALESCE(code_example, ''), category,
+	       order_num, COALESCE(searchable, ''), created_at, updated_at`
+
+func (r docSections) list(ctx context.Context, query string, args ...any) ([]DocSection, error) {
+	rows, err := r.read.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get doc sections: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	var sections []DocSection
+	for rows.Next() {
+		var section DocSection
+		err := rows.Scan(
+			&section.ID, &section.Title, &section.Slug, &section.Content,
+			&section.CodeExample, &section.Category, &section.Order,
+			&section.Searchable, &section.CreatedAt, &section.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan doc section: %w", err)
+		}
+		sections = append(sections, section)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating doc sections: %w", err)
+	}
+
+	return sections, nil
+}
+
+func (r docSections) All(ctx context.Context) ([]DocSection, error) {
+	return r.list(ctx, `SELECT `+docSectionColumns+`
+	FROM doc_sections
+	ORDER BY category, order_num, title`)
+}
+
+func (r docSections) GetBySlug(ctx context.Context, slug string) (*DocSection, error) {
+	sections, err := r.list(ctx, `SELECT `+docSectionColumns+` FROM doc_sections WHERE slug = ?`, slug)
+	if err != nil {
+		return nil, err
+	}
+	if len(sections) == 0 {
+		return nil, fmt.Errorf("failed to get doc section %q: %w", slug, ErrNotFound)
+	}
+	return &sections[0], nil
+}
+
+func (r docSections) ByCategory(ctx context.Context, category string) ([]DocSection, error) {
+	return r.list(ctx, `SELECT `+docSectionColumns+`
+	FROM doc_sections
+	WHERE category = ?
+	ORDER BY order_num, title`, category)
+}
+
+func (r docSections) Search(ctx context.Context, query string, limit int) ([]DocSection, error) {
+	term := "%" + query + "%"
+	return r.list(ctx, `SELECT `+docSectionColumns+`
+	FROM doc_sections
+	WHERE title LIKE ?1 OR content LIKE ?1 OR searchable LIKE ?1
+	ORDER BY
+		CASE
+			WHEN title LIKE ?1 THEN 1
+			WHEN content LIKE ?1 THEN 2
+			ELSE 3
+		END,
+		category, order_num, title
+	LIMIT ?2`, term, limit)
+}
+
+func (r docSections) Count(ctx context.Context) (int, error) {
+	var count int
+	if err := r.read.QueryRowContext(ctx, `SELECT COUNT(*) FROM doc_sections`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count doc sections: %w", mapError(err))
+	}
+	return count, nil
+}
+
+func (r docSections) Create(ctx context.Context, section DocSection) error {
+	query := `INSERT INTO doc_sections (title, slug, content, code_example, category, order_num, searchable)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := r.write.ExecContext(ctx, query, section.Title, section.Slug, section.Content, section.CodeExample,
+		section.Category, section.Order, section.Searchable)
+	if err != nil {
+		return fmt.Errorf("failed to create doc section %s: %w", section.Slug, mapError(err))
+	}
+	return nil
+}
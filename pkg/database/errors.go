@@ -0,0 +1,54 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Errors returned by DB methods, wrapped with the failing operation. Test
+// for them with errors.Is.
+var (
+	// ErrNotFound means the requested row does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict means a UNIQUE or PRIMARY KEY constraint rejected a write,
+	// such as registering a taken username.
+	ErrConflict = errors.New("conflict")
+	// ErrConstraint means another constraint rejected a write: NOT NULL,
+	// CHECK or FOREIGN KEY.
+	ErrConstraint = errors.New("constraint violation")
+)
+
+// mapError translates driver errors into the errors above, keeping the
+// driver's error in the chain for its message.
+func mapError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch code := sqliteErr.Code(); {
+		case code == sqlite3.SQLITE_CONSTRAINT_UNIQUE, code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+			return fmt.Errorf("%w: %w", ErrConflict, err)
+		case code&0xff == sqlite3.SQLITE_CONSTRAINT:
+			return fmt.Errorf("%w: %w", ErrConstraint, err)
+		}
+	}
+	return err
+}
+
+// updatedRow turns an UPDATE that matched no row into ErrNotFound.
+func updatedRow(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
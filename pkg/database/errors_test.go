@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTypedErrors(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	ctx := context.Background()
+	user, err := db.Users().Create(ctx, "gopher", "gopher@example.com", "", "hash")
+	if err != nil {
+		t.Fatalf("Users().Create() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{"missing user", func() error { _, err := db.Users().GetByID(ctx, user.ID+1); return err }, ErrNotFound},
+		{"missing username", func() error { _, err := db.Users().GetByUsername(ctx, "nobody"); return err }, ErrNotFound},
+		{"missing post", func() error { _, err := db.Posts().GetByID(ctx, 42); return err }, ErrNotFound},
+		{"duplicate username", func() error {
+			_, err := db.Users().Create(ctx, "gopher", "other@example.com", "", "hash")
+			return err
+		}, ErrConflict},
+		{"duplicate email", func() error {
+			_, err := db.Users().Create(ctx, "other", "gopher@example.com", "", "hash")
+			return err
+		}, ErrConflict},
+		{"not null", func() error {
+			_, err := db.conn.ExecContext(ctx, "INSERT INTO users (username, email) VALUES ('x', 'x@example.com')")
+			return mapError(err)
+		}, ErrConstraint},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanceledContext(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := db.Feed().Recent(ctx, 0, Cursor{}, 10); !errors.Is(err, context.Canceled) {
+		t.Errorf("Recent() with canceled context error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// FeedPost is a post as a timeline shows it to one viewer.
+type FeedPost struct {
+	Post
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	AvatarURL   string `json:"avatar_url"`
+	// IsLiked and IsFollowing are the viewer's like of the post and follow
+	// of its author; both are false for viewer 0.
+	IsLiked     bool `json:"is_liked"`
+	IsFollowing bool `json:"is_following"`
+}
+
+// Feed lists posts ready to display, each with its author, like count and
+// the viewer's state, in a single query per page. The listings return up
+// to limit posts, newest first, starting after the cursor.
+type Feed interface {
+	// Recent lists every post.
+	Recent(ctx context.Context, viewerID int, after Cursor, limit int) ([]FeedPost, error)
+	// Following lists the posts by viewerID and the users viewerID follows.
+	Following(ctx context.Context, viewerID int, after Cursor, limit int) ([]FeedPost, error)
+	// ByUser lists the posts by authorID.
+	ByUser(ctx context.Context, authorID, viewerID int, after Cursor, limit int) ([]FeedPost, error)
+}
+
+type feed store
+
+// feedQuery selects feed posts matching where, which may use ?1 for the
+// viewer and ?5 for an author. ?2 and ?3 are the cursor bounds and ?4 the
+// limit.
+func feedQuery(where string) string {
+	return `SELECT p.id, p.user_id, p.content, p.created_at, p.updated_at, p.like_count,
+				u.username, u.display_name, u.avatar_url,
+				EXISTS (SELECT 1 FROM likes l WHERE l.user_id = ?1 AND l.post_id = p.id),
+				EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = ?1 AND f.following_id = p.user_id)
+			FROM posts p
+			JOIN users u ON u.id = p.user_id
+			WHERE (` + where + `) AND (p.created_at, p.id) < (?2, ?3)
+			ORDER BY p.created_at DESC, p.id DESC
+			LIMIT ?4`
+}
+
+var (
+	feedRecentQuery    = feedQuery(`1`)
+	feedFollowingQuery = feedQuery(`p.user_id = ?1 OR p.user_id IN (SELECT following_id FROM follows WHERE follower_id = ?1)`)
+	feedByUserQuery    = feedQuery(`p.user_id = ?5`)
+)
+
+func (r feed) Recent(ctx context.Context, viewerID int, after Cursor, limit int) ([]FeedPost, error) {
+	createdAt, id := after.bounds()
+	return r.list(ctx, feedRecentQuery, viewerID, createdAt, id, limit)
+}
+
+func (r feed) Following(ctx context.Context, viewerID int, after Cursor, limit int) ([]FeedPost, error) {
+	createdAt, id := after.bounds()
+	return r.list(ctx, feedFollowingQuery, viewerID, createdAt, id, limit)
+}
+
+func (r feed) ByUser(ctx context.Context, authorID, viewerID int, after Cursor, limit int) ([]FeedPost, error) {
+	createdAt, id := after.bounds()
+	return r.list(ctx, feedByUserQuery, viewerID, createdAt, id, limit, authorID)
+}
+
+func (r feed) list(ctx context.Context, query string, args ...any) ([]FeedPost, error) {
+	rows, err := r.read.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	var result []FeedPost
+	for rows.Next() {
+		var p FeedPost
+		err := rows.Scan(
+			&p.ID, &p.UserID, &p.Content, &p.CreatedAt, &p.UpdatedAt, &p.LikeCount,
+			&p.Username, &p.DisplayName, &p.AvatarURL, &p.IsLiked, &p.IsFollowing,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan feed post: %w", err)
+		}
+		result = append(result, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating feed: %w", err)
+	}
+
+	return result, nil
+}
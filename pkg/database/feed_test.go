@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFeed(t *testing.T) {
+	ctx := context.Background()
+	db := newMigratedDB(t)
+
+	ids := make(map[string]int)
+	for _, name := range []string{"ann", "bob", "cat"} {
+		user, err := db.Users().Create(ctx, name, name+"@example.com", "", "h")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[name] = user.ID
+	}
+	post, err := db.Posts().Create(ctx, ids["bob"], "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Follows().Follow(ctx, ids["ann"], ids["bob"]); err != nil {
+		t.Fatal(err)
+	}
+	// The triggers count each like once, ignore repeats and follow unlikes
+	for _, step := range []struct {
+		user string
+		like bool
+	}{{"ann", true}, {"cat", true}, {"ann", true}, {"cat", false}} {
+		if step.like {
+			err = db.Likes().Like(ctx, ids[step.user], post.ID)
+		} else {
+			err = db.Likes().Unlike(ctx, ids[step.user], post.ID)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		viewer        string
+		wantLiked     bool
+		wantFollowing bool
+	}{
+		{"ann", true, true},
+		{"cat", false, false},
+		{"", false, false},
+	}
+	for _, tt := range tests {
+		posts, err := db.Feed().Recent(ctx, ids[tt.viewer], Cursor{}, 10)
+		if err != nil {
+			t.Fatalf("Recent() error = %v", err)
+		}
+		if len(posts) != 1 {
+			t.Fatalf("Recent() returned %d posts, want 1", len(posts))
+		}
+		p := posts[0]
+		if p.Username != "bob" || p.LikeCount != 1 {
+			t.Errorf("Recent() = %s with %d likes, want bob with 1", p.Username, p.LikeCount)
+		}
+		if p.IsLiked != tt.wantLiked || p.IsFollowing != tt.wantFollowing {
+			t.Errorf("viewer %q: IsLiked, IsFollowing = %v, %v, want %v, %v",
+				tt.viewer, p.IsLiked, p.IsFollowing, tt.wantLiked, tt.wantFollowing)
+		}
+	}
+
+	var count int
+	if err := db.ReadConnection().QueryRowContext(ctx, `SELECT COUNT(*) FROM likes WHERE post_id = ?`, post.ID).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := db.Likes().Count(ctx, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored != count {
+		t.Errorf("Count() = %d, COUNT(*) = %d", stored, count)
+	}
+}
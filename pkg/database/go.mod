@@ -4,9 +4,13 @@ go 1.24
 
 toolchain go1.24.5
 
-require modernc.org/sqlite v1.34.4
+require (
+	github.com/dunamismax/go-stdlib/pkg/tracing v0.0.0
+	modernc.org/sqlite v1.34.4
+)
 
 require (
+	github.com/dunamismax/go-stdlib/pkg/middleware v0.0.0 // indirect
 	github.com/dustin/go-humanize v1.0.1 // indirect
 	github.com/google/uuid v1.6.0 // indirect
 	github.com/hashicorp/golang-lru/v2 v2.0.7 // indirect
@@ -21,3 +25,7 @@ require (
 	modernc.org/strutil v1.2.0 // indirect
 	modernc.org/token v1.1.0 // indirect
 )
+
+replace github.com/dunamismax/go-stdlib/pkg/middleware => ../middleware
+
+replace github.com/dunamismax/go-stdlib/pkg/tracing => ../tracing
@@ -0,0 +1,418 @@
+package database
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var coreMigrations embed.FS
+
+// CoreMigrationSet is the name under which the shared users/posts/follows/likes
+// schema is tracked in schema_migrations.
+const CoreMigrationSet = "core"
+
+var (
+	// ErrChecksumMismatch is returned when an applied migration's SQL no longer
+	// matches the checksum recorded when it ran.
+	ErrChecksumMismatch = errors.New("migration checksum mismatch")
+
+	// ErrUnknownMigration is returned when the database records a migration
+	// version that is not present in the source set.
+	ErrUnknownMigration = errors.New("applied migration missing from source")
+
+	// ErrIrreversible is returned when rolling back a migration without a down script.
+	ErrIrreversible = errors.New("migration has no down script")
+)
+
+// Migration is a single numbered schema change loaded from NNNN_name.up.sql
+// and an optional NNNN_name.down.sql.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// MigrationStatus describes a migration and whether it has been applied.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type appliedMigration struct {
+	version   int
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Migrator applies and rolls back one named set of migrations. Each app owns
+// its own set so versions never collide across apps sharing a database.
+type Migrator struct {
+	db         *DB
+	set        string
+	migrations []Migration
+}
+
+// LoadMigrations reads NNNN_name.up.sql / NNNN_name.down.sql pairs from dir in fsys.
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		version, name, direction, err := parseMigrationFilename(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, name)
+		}
+
+		switch direction {
+		case "up":
+			m.Up = string(content)
+		case "down":
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		sum := sha256.Sum256([]byte(m.Up))
+		m.Checksum = hex.EncodeToString(sum[:])
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func parseMigrationFilename(filename string) (int, string, string, error) {
+	base := strings.TrimSuffix(filename, ".sql")
+
+	var direction string
+	switch {
+	case strings.HasSuffix(base, ".up"):
+		direction = "up"
+	case strings.HasSuffix(base, ".down"):
+		direction = "down"
+	default:
+		return 0, "", "", fmt.Errorf("migration %s must end in .up.sql or .down.sql", filename)
+	}
+	base = strings.TrimSuffix(base, "."+direction)
+
+	versionStr, name, ok := strings.Cut(base, "_")
+	if !ok || name == "" {
+		return 0, "", "", fmt.Errorf("migration %s must be named NNNN_name.%s.sql", filename, direction)
+	}
+
+	version, err := strconv.Atoi(versionStr)
+	if err != nil || version <= 0 {
+		return 0, "", "", fmt.Errorf("migration %s has an invalid version", filename)
+	}
+
+	return version, name, direction, nil
+}
+
+// NewMigrator loads the migrations in dir and returns a Migrator for the named set.
+func (db *DB) NewMigrator(set string, fsys fs.FS, dir string) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, set: set, migrations: migrations}, nil
+}
+
+// CoreMigrator returns a Migrator for the shared core schema.
+func (db *DB) CoreMigrator() (*Migrator, error) {
+	return db.NewMigrator(CoreMigrationSet, coreMigrations, "migrations")
+}
+
+// RunMigrations applies all pending migrations of a set in one call.
+func (db *DB) RunMigrations(set string, fsys fs.FS, dir string) error {
+	m, err := db.NewMigrator(set, fsys, dir)
+	if err != nil {
+		return err
+	}
+	return m.Up()
+}
+
+func (m *Migrator) ensureTable() error {
+	_, err := m.db.conn.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			set_name TEXT NOT NULL,
+			version INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (set_name, version)
+		)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+func (m *Migrator) applied() (map[int]appliedMigration, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.conn.Query(
+		`SELECT version, name, checksum, applied_at FROM schema_migrations WHERE set_name = ?`, m.set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var a appliedMigration
+		if err := rows.Scan(&a.version, &a.name, &a.checksum, &a.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %w", err)
+		}
+		applied[a.version] = a
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating schema_migrations: %w", err)
+	}
+
+	return applied, nil
+}
+
+// Verify checks that every applied migration still exists in the source set
+// and that its SQL has not been edited since it ran.
+func (m *Migrator) Verify() error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+	return m.verify(applied)
+}
+
+func (m *Migrator) verify(applied map[int]appliedMigration) error {
+	known := make(map[int]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	versions := make([]int, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+
+	for _, version := range versions {
+		a := applied[version]
+		migration, ok := known[version]
+		if !ok {
+			return fmt.Errorf("%w: %s %d_%s", ErrUnknownMigration, m.set, version, a.name)
+		}
+		if migration.Checksum != a.checksum {
+			return fmt.Errorf("%w: %s %d_%s", ErrChecksumMismatch, m.set, version, migration.Name)
+		}
+	}
+
+	return nil
+}
+
+// Up applies every pending migration in version order. Each migration runs in
+// its own transaction together with its schema_migrations row.
+func (m *Migrator) Up() error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	if err := m.verify(applied); err != nil {
+		return err
+	}
+
+	count := 0
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		slog.Info("Applying migration", "set", m.set, "version", migration.Version, "name", migration.Name)
+		err := m.inTx(func(tx *sql.Tx) error {
+			if _, err := tx.Exec(migration.Up); err != nil {
+				return err
+			}
+			_, err := tx.Exec(
+				`INSERT INTO schema_migrations (set_name, version, name, checksum) VALUES (?, ?, ?, ?)`,
+				m.set, migration.Version, migration.Name, migration.Checksum)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %s %d_%s: %w", m.set, migration.Version, migration.Name, err)
+		}
+		count++
+	}
+
+	slog.Info("Migrations up to date", "set", m.set, "applied", count)
+	return nil
+}
+
+// Down rolls back the most recent steps applied migrations.
+func (m *Migrator) Down(steps int) error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	if err := m.verify(applied); err != nil {
+		return err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		if err := m.rollback(migration); err != nil {
+			return err
+		}
+		steps--
+	}
+
+	return nil
+}
+
+// RollbackTo rolls back every applied migration with a version above target.
+// A target of 0 rolls back the whole set.
+func (m *Migrator) RollbackTo(target int) error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	if err := m.verify(applied); err != nil {
+		return err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version <= target {
+			break
+		}
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		if err := m.rollback(migration); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Migrator) rollback(migration Migration) error {
+	if strings.TrimSpace(migration.Down) == "" {
+		return fmt.Errorf("%w: %s %d_%s", ErrIrreversible, m.set, migration.Version, migration.Name)
+	}
+
+	slog.Info("Rolling back migration", "set", m.set, "version", migration.Version, "name", migration.Name)
+	err := m.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(migration.Down); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`DELETE FROM schema_migrations WHERE set_name = ? AND version = ?`, m.set, migration.Version)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to roll back migration %s %d_%s: %w", m.set, migration.Version, migration.Name, err)
+	}
+
+	return nil
+}
+
+// Status lists every migration in the set with its applied state.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if a, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = a.appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// Version returns the highest applied version in the set, or 0 if none.
+func (m *Migrator) Version() (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	version := 0
+	for v := range applied {
+		version = max(version, v)
+	}
+	return version, nil
+}
+
+func (m *Migrator) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := m.db.conn.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
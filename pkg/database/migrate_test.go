@@ -0,0 +1,105 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+	db, err := NewDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func testMigrations() fstest.MapFS {
+	return fstest.MapFS{
+		"m/0001_create_items.up.sql":   {Data: []byte("CREATE TABLE items (id INTEGER PRIMARY KEY);")},
+		"m/0001_create_items.down.sql": {Data: []byte("DROP TABLE items;")},
+		"m/0002_add_name.up.sql":       {Data: []byte("ALTER TABLE items ADD COLUMN name TEXT;")},
+		"m/0002_add_name.down.sql":     {Data: []byte("ALTER TABLE items DROP COLUMN name;")},
+	}
+}
+
+func TestMigratorUpAndDown(t *testing.T) {
+	db := newTestDB(t)
+
+	m, err := db.NewMigrator("test", testMigrations(), "m")
+	if err != nil {
+		t.Fatalf("NewMigrator() error = %v", err)
+	}
+
+	if err := m.Up(); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if _, err := db.conn.Exec("INSERT INTO items (name) VALUES ('a')"); err != nil {
+		t.Fatalf("insert after Up() error = %v", err)
+	}
+
+	// Running again is a no-op
+	if err := m.Up(); err != nil {
+		t.Fatalf("second Up() error = %v", err)
+	}
+
+	if err := m.Down(1); err != nil {
+		t.Fatalf("Down(1) error = %v", err)
+	}
+	if v, _ := m.Version(); v != 1 {
+		t.Errorf("Version() after Down(1) = %v, want %v", v, 1)
+	}
+
+	if err := m.RollbackTo(0); err != nil {
+		t.Fatalf("RollbackTo(0) error = %v", err)
+	}
+	if v, _ := m.Version(); v != 0 {
+		t.Errorf("Version() after RollbackTo(0) = %v, want %v", v, 0)
+	}
+}
+
+func TestMigratorChecksumDrift(t *testing.T) {
+	db := newTestDB(t)
+	fsys := testMigrations()
+
+	if err := db.RunMigrations("test", fsys, "m"); err != nil {
+		t.Fatalf("RunMigrations() error = %v", err)
+	}
+
+	fsys["m/0001_create_items.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE items (id INTEGER PRIMARY KEY, extra TEXT);")}
+	err := db.RunMigrations("test", fsys, "m")
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("RunMigrations() after edit error = %v, want %v", err, ErrChecksumMismatch)
+	}
+}
+
+func TestMigratorFailedMigrationRollsBack(t *testing.T) {
+	db := newTestDB(t)
+	fsys := fstest.MapFS{
+		"m/0001_broken.up.sql": {Data: []byte("CREATE TABLE broken (id INTEGER); INSERT INTO missing VALUES (1);")},
+	}
+
+	if err := db.RunMigrations("test", fsys, "m"); err == nil {
+		t.Fatal("RunMigrations() error = nil, want error")
+	}
+
+	var count int
+	db.conn.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'broken'").Scan(&count)
+	if count != 0 {
+		t.Errorf("table from failed migration exists, want it rolled back")
+	}
+}
+
+func TestMigrateCoreSchema(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if _, err := db.Users().Create(context.Background(), "gopher", "gopher@example.com", "", "hash"); err != nil {
+		t.Errorf("Users().Create() after Migrate() error = %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+CREATE TABLE IF NOT EXISTS users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	username TEXT UNIQUE NOT NULL,
+	email TEXT UNIQUE NOT NULL,
+	password_hash TEXT NOT NULL,
+	display_name TEXT DEFAULT '',
+	bio TEXT DEFAULT '',
+	avatar_url TEXT DEFAULT '',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS posts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	content TEXT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS follows (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	follower_id INTEGER NOT NULL,
+	following_id INTEGER NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (follower_id) REFERENCES users (id),
+	FOREIGN KEY (following_id) REFERENCES users (id),
+	UNIQUE (follower_id, following_id)
+);
+
+CREATE TABLE IF NOT EXISTS likes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	post_id INTEGER NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users (id),
+	FOREIGN KEY (post_id) REFERENCES posts (id),
+	UNIQUE (user_id, post_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_username ON users (username);
+CREATE INDEX IF NOT EXISTS idx_users_email ON users (email);
+CREATE INDEX IF NOT EXISTS idx_posts_user_id ON posts (user_id);
+CREATE INDEX IF NOT EXISTS idx_posts_created_at ON posts (created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_follows_follower_id ON follows (follower_id);
+CREATE INDEX IF NOT EXISTS idx_follows_following_id ON follows (following_id);
+CREATE INDEX IF NOT EXISTS idx_likes_user_id ON likes (user_id);
+CREATE INDEX IF NOT EXISTS idx_likes_post_id ON likes (post_id);
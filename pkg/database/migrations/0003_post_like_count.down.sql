@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS likes_count_delete;
+DROP TRIGGER IF EXISTS likes_count_insert;
+ALTER TABLE posts DROP COLUMN like_count;
@@ -0,0 +1,15 @@
+-- Feeds read each post's like count from the post itself; the triggers keep
+-- it in step with the likes table.
+ALTER TABLE posts ADD COLUMN like_count INTEGER NOT NULL DEFAULT 0;
+
+UPDATE posts SET like_count = (SELECT COUNT(*) FROM likes WHERE likes.post_id = posts.id);
+
+CREATE TRIGGER IF NOT EXISTS likes_count_insert AFTER INSERT ON likes
+BEGIN
+	UPDATE posts SET like_count = like_count + 1 WHERE id = NEW.post_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS likes_count_delete AFTER DELETE ON likes
+BEGIN
+	UPDATE posts SET like_count = like_count - 1 WHERE id = OLD.post_id;
+END;
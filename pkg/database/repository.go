@@ -0,0 +1,347 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Store gives access to the repositories. *DB implements it with each
+// statement committing on its own; the Tx passed to WithTx implements it
+// with every statement in one transaction.
+type Store interface {
+	Users() Users
+	Posts() Posts
+	Feed() Feed
+	Follows() Follows
+	Likes() Likes
+	DocSections() DocSections
+}
+
+type Users interface {
+	Create(ctx context.Context, username, email, displayName, passwordHash string) (*User, error)
+	GetByID(ctx context.Context, id int) (*User, error)
+	GetByUsername(ctx context.Context, username string) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	UpdatePasswordHash(ctx context.Context, userID int, passwordHash string) error
+	UpdateProfile(ctx context.Context, userID int, displayName, bio string) (*User, error)
+	UpdateAvatarURL(ctx context.Context, userID int, avatarURL string) error
+}
+
+type Posts interface {
+	Create(ctx context.Context, userID int, content string) (*Post, error)
+	GetByID(ctx context.Context, id int) (*Post, error)
+}
+
+type Follows interface {
+	// Follow is a no-op when the follow already exists.
+	Follow(ctx context.Context, followerID, followingID int) error
+	Unfollow(ctx context.Context, followerID, followingID int) error
+	IsFollowing(ctx context.Context, followerID, followingID int) (bool, error)
+	Counts(ctx context.Context, userID int) (followers, following int, err error)
+	// Followers and Following list users, most recently followed first,
+	// each with whether viewerID follows them, in a single query.
+	Followers(ctx context.Context, userID, viewerID, limit int) ([]FollowUser, error)
+	Following(ctx context.Context, userID, viewerID, limit int) ([]FollowUser, error)
+}
+
+// FollowUser is a user in a followers or following list.
+type FollowUser struct {
+	User
+	// IsFollowing is the viewer's follow of the user; false for viewer 0.
+	IsFollowing bool `json:"is_following"`
+}
+
+type Likes interface {
+	// Like is a no-op when the like already exists.
+	Like(ctx context.Context, userID, postID int) error
+	Unlike(ctx context.Context, userID, postID int) error
+	// Count reads the post's trigger-maintained like_count; ErrNotFound
+	// when the post does not exist.
+	Count(ctx context.Context, postID int) (int, error)
+	IsLiked(ctx context.Context, userID, postID int) (bool, error)
+}
+
+// querier is the part of *sql.DB and *sql.Tx the repositories use.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// store implements Store. On a DB, reads go to the read-only pool and
+// writes to the writer; in a Tx both are the transaction.
+type store struct {
+	read  querier
+	write querier
+}
+
+func (s store) Users() Users             { return users(s) }
+func (s store) Posts() Posts             { return posts(s) }
+func (s store) Feed() Feed               { return feed(s) }
+func (s store) Follows() Follows         { return follows(s) }
+func (s store) Likes() Likes             { return likes(s) }
+func (s store) DocSections() DocSections { return docSections(s) }
+
+type users store
+
+const userColumns = `id, username, email, password_hash, display_name, bio, avatar_url, created_at, updated_at`
+
+// prefixColumns qualifies each column in columns with table, for joins.
+func prefixColumns(table, columns string) string {
+	cols := strings.Split(columns, ", ")
+	for i, col := range cols {
+		cols[i] = table + "." + col
+	}
+	return strings.Join(cols, ", ")
+}
+
+func scanUser(row interface{ Scan(...any) error }) (*User, error) {
+	var user User
+	err := row.Scan(
+		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
+		&user.DisplayName, &user.Bio, &user.AvatarURL, &user.CreatedAt, &user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Create inserts and reads back the user in one statement, so the result
+// includes the defaults SQLite filled in.
+func (r users) Create(ctx context.Context, username, email, displayName, passwordHash string) (*User, error) {
+	query := `INSERT INTO users (username, email, display_name, password_hash) VALUES (?, ?, ?, ?)
+			 RETURNING ` + userColumns
+
+	user, err := scanUser(r.write.QueryRowContext(ctx, query, username, email, displayName, passwordHash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", mapError(err))
+	}
+	return user, nil
+}
+
+func (r users) GetByID(ctx context.Context, id int) (*User, error) {
+	user, err := scanUser(r.read.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = ?`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", mapError(err))
+	}
+	return user, nil
+}
+
+func (r users) GetByUsername(ctx context.Context, username string) (*User, error) {
+	user, err := scanUser(r.read.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE username = ?`, username))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", mapError(err))
+	}
+	return user, nil
+}
+
+func (r users) GetByEmail(ctx context.Context, email string) (*User, error) {
+	user, err := scanUser(r.read.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE email = ?`, email))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", mapError(err))
+	}
+	return user, nil
+}
+
+func (r users) UpdatePasswordHash(ctx context.Context, userID int, passwordHash string) error {
+	query := `UPDATE users SET password_hash = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+
+	result, err := r.write.ExecContext(ctx, query, passwordHash, userID)
+	if err == nil {
+		err = updatedRow(result)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update password hash: %w", mapError(err))
+	}
+	return nil
+}
+
+func (r users) UpdateAvatarURL(ctx context.Context, userID int, avatarURL string) error {
+	query := `UPDATE users SET avatar_url = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+
+	result, err := r.write.ExecContext(ctx, query, avatarURL, userID)
+	if err == nil {
+		err = updatedRow(result)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update avatar: %w", mapError(err))
+	}
+	return nil
+}
+
+func (r users) UpdateProfile(ctx context.Context, userID int, displayName, bio string) (*User, error) {
+	query := `UPDATE users SET display_name = ?, bio = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?
+			 RETURNING ` + userColumns
+
+	user, err := scanUser(r.write.QueryRowContext(ctx, query, displayName, bio, userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update profile: %w", mapError(err))
+	}
+	return user, nil
+}
+
+type posts store
+
+const postColumns = `id, user_id, content, created_at, updated_at, like_count`
+
+func scanPost(row interface{ Scan(...any) error }) (*Post, error) {
+	var post Post
+	if err := row.Scan(&post.ID, &post.UserID, &post.Content, &post.CreatedAt, &post.UpdatedAt, &post.LikeCount); err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+func (r posts) Create(ctx context.Context, userID int, content string) (*Post, error) {
+	query := `INSERT INTO posts (user_id, content) VALUES (?, ?) RETURNING ` + postColumns
+
+	post, err := scanPost(r.write.QueryRowContext(ctx, query, userID, content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create post: %w", mapError(err))
+	}
+	return post, nil
+}
+
+func (r posts) GetByID(ctx context.Context, id int) (*Post, error) {
+	post, err := scanPost(r.read.QueryRowContext(ctx, `SELECT `+postColumns+` FROM posts WHERE id = ?`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post: %w", mapError(err))
+	}
+	return post, nil
+}
+
+type follows store
+
+func (r follows) Follow(ctx context.Context, followerID, followingID int) error {
+	query := `INSERT INTO follows (follower_id, following_id) VALUES (?, ?) ON CONFLICT DO NOTHING`
+
+	if _, err := r.write.ExecContext(ctx, query, followerID, followingID); err != nil {
+		return fmt.Errorf("failed to follow user: %w", mapError(err))
+	}
+	return nil
+}
+
+func (r follows) Unfollow(ctx context.Context, followerID, followingID int) error {
+	query := `DELETE FROM follows WHERE follower_id = ? AND following_id = ?`
+
+	if _, err := r.write.ExecContext(ctx, query, followerID, followingID); err != nil {
+		return fmt.Errorf("failed to unfollow user: %w", mapError(err))
+	}
+	return nil
+}
+
+func (r follows) IsFollowing(ctx context.Context, followerID, followingID int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM follows WHERE follower_id = ? AND following_id = ?)`
+
+	var following bool
+	if err := r.read.QueryRowContext(ctx, query, followerID, followingID).Scan(&following); err != nil {
+		return false, fmt.Errorf("failed to check follow status: %w", mapError(err))
+	}
+	return following, nil
+}
+
+func (r follows) Counts(ctx context.Context, userID int) (followers, following int, err error) {
+	query := `SELECT
+				(SELECT COUNT(*) FROM follows WHERE following_id = ?1),
+				(SELECT COUNT(*) FROM follows WHERE follower_id = ?1)`
+
+	if err := r.read.QueryRowContext(ctx, query, userID).Scan(&followers, &following); err != nil {
+		return 0, 0, fmt.Errorf("failed to count follows: %w", mapError(err))
+	}
+	return followers, following, nil
+}
+
+// followListQuery selects the users joined to follows f on join, matching
+// where, with ?1 for the viewer, ?2 for the listed user and ?3 the limit.
+func followListQuery(join, where string) string {
+	return `SELECT ` + prefixColumns("u", userColumns) + `,
+				EXISTS (SELECT 1 FROM follows v WHERE v.follower_id = ?1 AND v.following_id = u.id)
+			FROM follows f
+			JOIN users u ON u.id = ` + join + `
+			WHERE ` + where + `
+			ORDER BY f.created_at DESC, f.id DESC
+			LIMIT ?3`
+}
+
+var (
+	followersQuery = followListQuery(`f.follower_id`, `f.following_id = ?2`)
+	followingQuery = followListQuery(`f.following_id`, `f.follower_id = ?2`)
+)
+
+func (r follows) Followers(ctx context.Context, userID, viewerID, limit int) ([]FollowUser, error) {
+	return r.list(ctx, followersQuery, viewerID, userID, limit)
+}
+
+func (r follows) Following(ctx context.Context, userID, viewerID, limit int) ([]FollowUser, error) {
+	return r.list(ctx, followingQuery, viewerID, userID, limit)
+}
+
+func (r follows) list(ctx context.Context, query string, args ...any) ([]FollowUser, error) {
+	rows, err := r.read.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	var result []FollowUser
+	for rows.Next() {
+		var u FollowUser
+		err := rows.Scan(
+			&u.ID, &u.Username, &u.Email, &u.PasswordHash,
+			&u.DisplayName, &u.Bio, &u.AvatarURL, &u.CreatedAt, &u.UpdatedAt, &u.IsFollowing,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		result = append(result, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating users: %w", err)
+	}
+
+	return result, nil
+}
+
+type likes store
+
+func (r likes) Like(ctx context.Context, userID, postID int) error {
+	query := `INSERT INTO likes (user_id, post_id) VALUES (?, ?) ON CONFLICT DO NOTHING`
+
+	if _, err := r.write.ExecContext(ctx, query, userID, postID); err != nil {
+		return fmt.Errorf("failed to like post: %w", mapError(err))
+	}
+	return nil
+}
+
+func (r likes) Unlike(ctx context.Context, userID, postID int) error {
+	query := `DELETE FROM likes WHERE user_id = ? AND post_id = ?`
+
+	if _, err := r.write.ExecContext(ctx, query, userID, postID); err != nil {
+		return fmt.Errorf("failed to unlike post: %w", mapError(err))
+	}
+	return nil
+}
+
+func (r likes) Count(ctx context.Context, postID int) (int, error) {
+	query := `SELECT like_count FROM posts WHERE id = ?`
+
+	var count int
+	if err := r.read.QueryRowContext(ctx, query, postID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to get like count: %w", mapError(err))
+	}
+	return count, nil
+}
+
+func (r likes) IsLiked(ctx context.Context, userID, postID int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM likes WHERE user_id = ? AND post_id = ?)`
+
+	var liked bool
+	if err := r.read.QueryRowContext(ctx, query, userID, postID).Scan(&liked); err != nil {
+		return false, fmt.Errorf("failed to check like status: %w", mapError(err))
+	}
+	return liked, nil
+}
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFollows(t *testing.T) {
+	ctx := context.Background()
+	db := newMigratedDB(t)
+
+	ids := make(map[string]int)
+	for _, name := range []string{"ann", "bob", "cat"} {
+		user, err := db.Users().Create(ctx, name, name+"@example.com", "", "h")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[name] = user.ID
+		if _, err := db.Posts().Create(ctx, user.ID, "hello from "+name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// ann follows bob and cat; cat follows ann. Following twice is a no-op.
+	for _, f := range [][2]string{{"ann", "bob"}, {"ann", "cat"}, {"cat", "ann"}, {"ann", "bob"}} {
+		if err := db.Follows().Follow(ctx, ids[f[0]], ids[f[1]]); err != nil {
+			t.Fatalf("Follow(%s, %s) error = %v", f[0], f[1], err)
+		}
+	}
+
+	followers, following, err := db.Follows().Counts(ctx, ids["ann"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if followers != 1 || following != 2 {
+		t.Errorf("Counts(ann) = %d, %d, want 1, 2", followers, following)
+	}
+
+	// Seen by cat, ann's following list is bob, whom cat does not follow,
+	// and cat themselves; cat's followers are ann, whom cat follows.
+	users, err := db.Follows().Following(ctx, ids["ann"], ids["cat"], 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("Following(ann) returned %d users, want 2", len(users))
+	}
+	for _, u := range users {
+		if u.IsFollowing {
+			t.Errorf("Following(ann) seen by cat: %s IsFollowing = true, want false", u.Username)
+		}
+	}
+
+	users, err = db.Follows().Followers(ctx, ids["cat"], ids["cat"], 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].Username != "ann" || !users[0].IsFollowing {
+		t.Errorf("Followers(cat) seen by cat = %v, want [ann] followed", users)
+	}
+
+	users, err = db.Follows().Followers(ctx, ids["ann"], 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].Username != "cat" || users[0].IsFollowing {
+		t.Errorf("Followers(ann) seen anonymously = %v, want [cat] not followed", users)
+	}
+
+	tests := []struct {
+		user string
+		want int
+	}{
+		{"ann", 3}, // ann's own post and both followed users'
+		{"bob", 1}, // follows nobody
+		{"cat", 2},
+	}
+	for _, tt := range tests {
+		posts, err := db.Feed().Following(ctx, ids[tt.user], Cursor{}, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(posts) != tt.want {
+			t.Errorf("Feed().Following(%s) returned %d posts, want %d", tt.user, len(posts), tt.want)
+		}
+	}
+
+	if err := db.Follows().Unfollow(ctx, ids["ann"], ids["bob"]); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := db.Follows().IsFollowing(ctx, ids["ann"], ids["bob"]); err != nil || ok {
+		t.Errorf("IsFollowing(ann, bob) after Unfollow = %v, %v, want false", ok, err)
+	}
+}
+
+func TestUpdatePasswordHash(t *testing.T) {
+	ctx := context.Background()
+	db := newMigratedDB(t)
+
+	user, err := db.Users().Create(ctx, "ann", "ann@example.com", "", "old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Users().UpdatePasswordHash(ctx, user.ID, "new"); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := db.Users().GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.PasswordHash != "new" {
+		t.Errorf("GetByID() hash = %q after UpdatePasswordHash, want %q", stored.PasswordHash, "new")
+	}
+
+	if err := db.Users().UpdatePasswordHash(ctx, user.ID+1, "new"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("UpdatePasswordHash(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestUserProfile(t *testing.T) {
+	ctx := context.Background()
+	db := newMigratedDB(t)
+
+	user, err := db.Users().Create(ctx, "ann", "ann@example.com", "Ann Lee", "h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.DisplayName != "Ann Lee" {
+		t.Errorf("Create() display name = %q, want %q", user.DisplayName, "Ann Lee")
+	}
+
+	updated, err := db.Users().UpdateProfile(ctx, user.ID, "Ann", "Writes about Go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.DisplayName != "Ann" || updated.Bio != "Writes about Go" {
+		t.Errorf("UpdateProfile() = %q, %q, want %q, %q", updated.DisplayName, updated.Bio, "Ann", "Writes about Go")
+	}
+
+	stored, err := db.Users().GetByUsername(ctx, "ann")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.DisplayName != "Ann" || stored.Bio != "Writes about Go" {
+		t.Errorf("GetByUsername() = %q, %q after UpdateProfile", stored.DisplayName, stored.Bio)
+	}
+
+	if err := db.Users().UpdateAvatarURL(ctx, user.ID, "/avatars/1-ab.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if stored, err = db.Users().GetByID(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if stored.AvatarURL != "/avatars/1-ab.jpg" {
+		t.Errorf("GetByID() avatar = %q after UpdateAvatarURL", stored.AvatarURL)
+	}
+
+	if _, err := db.Users().UpdateProfile(ctx, user.ID+1, "x", ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("UpdateProfile(missing) error = %v, want ErrNotFound", err)
+	}
+	if err := db.Users().UpdateAvatarURL(ctx, user.ID+1, "/avatars/2-cd.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("UpdateAvatarURL(missing) error = %v, want ErrNotFound", err)
+	}
+}
@@ -1,18 +1,29 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
 )
 
+// DB is the application database. Its repositories, from the embedded
+// store, run each statement on its own; use WithTx to group them.
 type DB struct {
-	conn *sql.DB
+	conn   *sql.DB // the single writer
+	reader *sql.DB
+	store
+	config Config
+	path   string
 }
 
 type User struct {
@@ -33,6 +44,8 @@ type Post struct {
 	Content   string    `json:"content"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// LikeCount is maintained by triggers on the likes table
+	LikeCount int `json:"like_count"`
 }
 
 type Follow struct {
@@ -49,283 +62,234 @@ type Like struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
-func NewDB(dataDir string) (*DB, error) {
-	if err := os.MkdirAll(dataDir, 0750); err != nil {
-		return nil, fmt.Errorf("failed to create data directory: %w", err)
-	}
-
-	dbPath := filepath.Join(dataDir, "app.db")
-	slog.Info("Opening database", "path", dbPath)
-	conn, err := sql.Open("sqlite", dbPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
-	}
-
-	// Configure connection
-	conn.SetMaxOpenConns(1)
-	conn.SetMaxIdleConns(1)
-	conn.SetConnMaxLifetime(0)
-
-	return &DB{
-		conn: conn,
-	}, nil
-}
-
-func (db *DB) Close() error {
-	return db.conn.Close()
+// Config configures Open. The zero value opens ./data/app.db in WAL mode
+// with foreign keys enforced, tuned for a web server's mix of many reads and
+// few writes.
+type Config struct {
+	// DataDir holds app.db. Default "./data".
+	DataDir string
+	// JournalMode default "wal", which lets the read pool run alongside the
+	// writer.
+	JournalMode string `config:"journal_mode" usage:"SQLite journal mode: wal, delete, truncate, persist or memory"`
+	// BusyTimeout is how long a statement waits for a lock before failing
+	// with SQLITE_BUSY. Default 5s.
+	BusyTimeout time.Duration `config:"busy_timeout" usage:"how long a statement waits for a database lock"`
+	// Synchronous default "normal", durable across crashes in WAL mode
+	// though a power loss may roll back the last commits.
+	Synchronous string `config:"synchronous" usage:"SQLite synchronous level: off, normal, full or extra"`
+	// CacheSizeKiB is each connection's page cache. Default 16384 (16 MiB).
+	CacheSizeKiB int `config:"cache_size_kib" usage:"page cache per connection in KiB"`
+	// MmapSize is how much of the file is memory-mapped, in bytes. Default
+	// 128 MiB; -1 turns mmap off.
+	MmapSize int64 `config:"mmap_size" usage:"bytes of the database file to memory-map, -1 for none"`
+	// ReadConns bounds the read-only pool. Default max(4, GOMAXPROCS).
+	ReadConns int `config:"read_conns" usage:"connections in the read-only pool"`
+	// DisableForeignKeys stops SQLite enforcing FOREIGN KEY clauses.
+	DisableForeignKeys bool `config:"disable_foreign_keys" usage:"do not enforce foreign keys"`
 }
 
-// GetConnection returns the underlying sql.DB connection
-func (db *DB) GetConnection() *sql.DB {
-	return db.conn
+// WithDefaults returns c with zero values replaced by the defaults.
+func (c Config) WithDefaults() Config {
+	if c.DataDir == "" {
+		c.DataDir = "./data"
+	}
+	if c.JournalMode == "" {
+		c.JournalMode = "wal"
+	}
+	if c.BusyTimeout == 0 {
+		c.BusyTimeout = 5 * time.Second
+	}
+	if c.Synchronous == "" {
+		c.Synchronous = "normal"
+	}
+	if c.CacheSizeKiB == 0 {
+		c.CacheSizeKiB = 16384
+	}
+	if c.MmapSize == 0 {
+		c.MmapSize = 128 << 20
+	}
+	if c.MmapSize < 0 {
+		c.MmapSize = 0
+	}
+	if c.ReadConns == 0 {
+		c.ReadConns = max(4, runtime.GOMAXPROCS(0))
+	}
+	return c
 }
 
-func (db *DB) Migrate() error {
-	slog.Info("Running database migrations")
-	// Create tables
-	schema := `
-		CREATE TABLE IF NOT EXISTS users (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			username TEXT UNIQUE NOT NULL,
-			email TEXT UNIQUE NOT NULL,
-			password_hash TEXT NOT NULL,
-			display_name TEXT DEFAULT '',
-			bio TEXT DEFAULT '',
-			avatar_url TEXT DEFAULT '',
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		);
-
-		CREATE TABLE IF NOT EXISTS posts (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			user_id INTEGER NOT NULL,
-			content TEXT NOT NULL,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			FOREIGN KEY (user_id) REFERENCES users (id)
-		);
-
-		CREATE TABLE IF NOT EXISTS follows (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			follower_id INTEGER NOT NULL,
-			following_id INTEGER NOT NULL,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			FOREIGN KEY (follower_id) REFERENCES users (id),
-			FOREIGN KEY (following_id) REFERENCES users (id),
-			UNIQUE (follower_id, following_id)
-		);
-
-		CREATE TABLE IF NOT EXISTS likes (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			user_id INTEGER NOT NULL,
-			post_id INTEGER NOT NULL,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			FOREIGN KEY (user_id) REFERENCES users (id),
-			FOREIGN KEY (post_id) REFERENCES posts (id),
-			UNIQUE (user_id, post_id)
-		);
-
-		CREATE INDEX IF NOT EXISTS idx_users_username ON users (username);
-		CREATE INDEX IF NOT EXISTS idx_users_email ON users (email);
-		CREATE INDEX IF NOT EXISTS idx_posts_user_id ON posts (user_id);
-		CREATE INDEX IF NOT EXISTS idx_posts_created_at ON posts (created_at DESC);
-		CREATE INDEX IF NOT EXISTS idx_follows_follower_id ON follows (follower_id);
-		CREATE INDEX IF NOT EXISTS idx_follows_following_id ON follows (following_id);
-		CREATE INDEX IF NOT EXISTS idx_likes_user_id ON likes (user_id);
-		CREATE INDEX IF NOT EXISTS idx_likes_post_id ON likes (post_id);
-	`
-
-	_, err := db.conn.Exec(schema)
-	if err != nil {
-		slog.Error("Failed to create tables", "error", err)
-		return fmt.Errorf("failed to create tables: %w", err)
+func (c Config) validate() error {
+	switch strings.ToLower(c.JournalMode) {
+	case "wal", "delete", "truncate", "persist", "memory":
+	default:
+		return fmt.Errorf("unknown journal mode %q", c.JournalMode)
+	}
+	switch strings.ToLower(c.Synchronous) {
+	case "off", "normal", "full", "extra":
+	default:
+		return fmt.Errorf("unknown synchronous level %q", c.Synchronous)
+	}
+	if c.ReadConns < 0 || c.CacheSizeKiB < 0 || c.BusyTimeout < 0 {
+		return fmt.Errorf("read_conns, cache_size_kib and busy_timeout must not be negative")
 	}
-
-	slog.Info("Database migrations completed successfully")
 	return nil
 }
 
-func (db *DB) GetUserByUsername(username string) (*User, error) {
-	query := `SELECT id, username, email, password_hash, display_name, bio, avatar_url, created_at, updated_at 
-			 FROM users WHERE username = ?`
-
-	var user User
-	err := db.conn.QueryRow(query, username).Scan(
-		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
-		&user.DisplayName, &user.Bio, &user.AvatarURL, &user.CreatedAt, &user.UpdatedAt,
-	)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
-		}
-		return nil, fmt.Errorf("failed to get user: %w", err)
+// pragmas returns the DSN parameters every connection is opened with.
+func (c Config) pragmas() url.Values {
+	foreignKeys := 1
+	if c.DisableForeignKeys {
+		foreignKeys = 0
 	}
-
-	return &user, nil
+	return url.Values{"_pragma": {
+		fmt.Sprintf("busy_timeout(%d)", c.BusyTimeout.Milliseconds()),
+		fmt.Sprintf("foreign_keys(%d)", foreignKeys),
+		fmt.Sprintf("synchronous(%s)", c.Synchronous),
+		fmt.Sprintf("cache_size(%d)", -c.CacheSizeKiB),
+		fmt.Sprintf("mmap_size(%d)", c.MmapSize),
+	}}
 }
 
-func (db *DB) GetUserByEmail(email string) (*User, error) {
-	query := `SELECT id, username, email, password_hash, display_name, bio, avatar_url, created_at, updated_at 
-			 FROM users WHERE email = ?`
-
-	var user User
-	err := db.conn.QueryRow(query, email).Scan(
-		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
-		&user.DisplayName, &user.Bio, &user.AvatarURL, &user.CreatedAt, &user.UpdatedAt,
-	)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
-		}
-		return nil, fmt.Errorf("failed to get user: %w", err)
-	}
-
-	return &user, nil
+// NewDB opens app.db in dataDir with the default Config.
+func NewDB(dataDir string) (*DB, error) {
+	return Open(Config{DataDir: dataDir})
 }
 
-func (db *DB) CreateUser(username, email, passwordHash string) (*User, error) {
-	query := `INSERT INTO users (username, email, password_hash) VALUES (?, ?, ?)`
-
-	result, err := db.conn.Exec(query, username, email, passwordHash)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+// Open opens app.db with one writing connection and a pool of read-only
+// ones. Repositories on the returned DB send reads to the pool, so with WAL
+// they no longer queue behind writes.
+func Open(config Config) (*DB, error) {
+	config = config.WithDefaults()
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid database config: %w", err)
 	}
-
-	id, err := result.LastInsertId()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get user ID: %w", err)
+	if err := os.MkdirAll(config.DataDir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
 	}
 
-	// Return the created user
-	return db.GetUserByID(int(id))
-}
-
-func (db *DB) GetUserByID(id int) (*User, error) {
-	query := `SELECT id, username, email, password_hash, display_name, bio, avatar_url, created_at, updated_at 
-			 FROM users WHERE id = ?`
-
-	var user User
-	err := db.conn.QueryRow(query, id).Scan(
-		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
-		&user.DisplayName, &user.Bio, &user.AvatarURL, &user.CreatedAt, &user.UpdatedAt,
-	)
+	dbPath := filepath.Join(config.DataDir, "app.db")
+	slog.Info("Opening database", "path", dbPath)
 
+	// sql.Open only looks the driver up; tracedConnector wraps it so queries
+	// show up in traces
+	sqlite, err := sql.Open("sqlite", dbPath)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
-		}
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
-
-	return &user, nil
-}
-
-func (db *DB) GetRecentPosts(limit int) ([]Post, error) {
-	query := `SELECT id, user_id, content, created_at, updated_at 
-			 FROM posts ORDER BY created_at DESC LIMIT ?`
-
-	rows, err := db.conn.Query(query, limit)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get posts: %w", err)
+	drv := sqlite.Driver()
+	sqlite.Close()
+
+	// The writer sets the journal mode, which is stored in the file, and its
+	// transactions BEGIN IMMEDIATE to take the write lock up front instead
+	// of failing when a read upgrades to a write
+	params := config.pragmas()
+	params.Add("_pragma", fmt.Sprintf("journal_mode(%s)", config.JournalMode))
+	params.Set("_txlock", "immediate")
+	writer := sql.OpenDB(tracedConnector{dsn: dbPath + "?" + params.Encode(), driver: drv})
+	writer.SetMaxOpenConns(1)
+	writer.SetMaxIdleConns(1)
+	writer.SetConnMaxLifetime(0)
+
+	// Connect the writer first so readers open a file already in WAL mode
+	ctx, cancel := context.WithTimeout(context.Background(), config.BusyTimeout+5*time.Second)
+	defer cancel()
+	if err := writer.PingContext(ctx); err != nil {
+		writer.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
-	defer rows.Close()
 
-	var posts []Post
-	for rows.Next() {
-		var post Post
-		err := rows.Scan(&post.ID, &post.UserID, &post.Content, &post.CreatedAt, &post.UpdatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan post: %w", err)
-		}
-		posts = append(posts, post)
+	params = config.pragmas()
+	params.Add("_pragma", "query_only(1)")
+	reader := sql.OpenDB(tracedConnector{dsn: dbPath + "?" + params.Encode(), driver: drv})
+	reader.SetMaxOpenConns(config.ReadConns)
+	reader.SetMaxIdleConns(config.ReadConns)
+	reader.SetConnMaxLifetime(0)
+
+	db := &DB{
+		conn:   writer,
+		reader: reader,
+		store:  store{read: reader, write: writer},
+		config: config,
+		path:   dbPath,
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating posts: %w", err)
+	if err := db.checkPragmas(ctx, config); err != nil {
+		db.Close()
+		return nil, err
 	}
 
-	return posts, nil
+	return db, nil
 }
 
-func (db *DB) CreatePost(userID int, content string) (*Post, error) {
-	query := `INSERT INTO posts (user_id, content) VALUES (?, ?)`
-
-	result, err := db.conn.Exec(query, userID, content)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create post: %w", err)
+// checkPragmas logs the settings SQLite actually applied, which can differ
+// from the requested ones: WAL, for one, is unavailable on some network
+// filesystems.
+func (db *DB) checkPragmas(ctx context.Context, config Config) error {
+	names := []string{"journal_mode", "busy_timeout", "foreign_keys", "synchronous", "cache_size", "mmap_size", "query_only"}
+	effective := make(map[string]map[string]string, 2)
+	for pool, conn := range map[string]*sql.DB{"write": db.conn, "read": db.reader} {
+		values := make(map[string]string, len(names))
+		for _, name := range names {
+			var value string
+			if err := conn.QueryRowContext(ctx, "PRAGMA "+name).Scan(&value); err != nil {
+				return fmt.Errorf("failed to read %s pragma %s: %w", pool, name, err)
+			}
+			values[name] = value
+		}
+		effective[pool] = values
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get post ID: %w", err)
+	slog.Info("Database pragmas", "write", effective["write"], "read", effective["read"])
+	if mode := effective["write"]["journal_mode"]; !strings.EqualFold(mode, config.JournalMode) {
+		slog.Warn("Database journal mode differs from the requested one", "requested", config.JournalMode, "effective", mode)
 	}
-
-	// Return the created post
-	return db.GetPostByID(int(id))
+	return nil
 }
 
-func (db *DB) GetPostByID(id int) (*Post, error) {
-	query := `SELECT id, user_id, content, created_at, updated_at FROM posts WHERE id = ?`
-
-	var post Post
-	err := db.conn.QueryRow(query, id).Scan(
-		&post.ID, &post.UserID, &post.Content, &post.CreatedAt, &post.UpdatedAt,
-	)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("post not found")
-		}
-		return nil, fmt.Errorf("failed to get post: %w", err)
-	}
-
-	return &post, nil
+// Close closes both pools.
+func (db *DB) Close() error {
+	return errors.Join(db.reader.Close(), db.conn.Close())
 }
 
-func (db *DB) LikePost(userID, postID int) error {
-	query := `INSERT INTO likes (user_id, post_id) VALUES (?, ?) ON CONFLICT DO NOTHING`
-
-	_, err := db.conn.Exec(query, userID, postID)
-	if err != nil {
-		return fmt.Errorf("failed to like post: %w", err)
-	}
-
-	return nil
+// GetConnection returns the writing sql.DB for infrastructure such as
+// session and rate limit stores. Application data goes through the
+// repositories instead.
+func (db *DB) GetConnection() *sql.DB {
+	return db.conn
 }
 
-func (db *DB) UnlikePost(userID, postID int) error {
-	query := `DELETE FROM likes WHERE user_id = ? AND post_id = ?`
+// ReadConnection returns the read-only pool, for infrastructure stores'
+// lookups and for exporting its stats next to the writer's.
+func (db *DB) ReadConnection() *sql.DB {
+	return db.reader
+}
 
-	_, err := db.conn.Exec(query, userID, postID)
-	if err != nil {
-		return fmt.Errorf("failed to unlike post: %w", err)
+// Ping verifies both pools answer a query within ctx. With a single
+// writing connection it also fails when that connection stays busy past
+// the deadline.
+func (db *DB) Ping(ctx context.Context) error {
+	var one int
+	for _, conn := range []*sql.DB{db.conn, db.reader} {
+		if err := conn.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+			return fmt.Errorf("failed to ping database: %w", err)
+		}
 	}
-
 	return nil
 }
 
-func (db *DB) GetLikeCount(postID int) (int, error) {
-	query := `SELECT COUNT(*) FROM likes WHERE post_id = ?`
+// Migrate applies the pending core schema migrations shared by all apps.
+func (db *DB) Migrate() error {
+	slog.Info("Running database migrations")
 
-	var count int
-	err := db.conn.QueryRow(query, postID).Scan(&count)
+	m, err := db.CoreMigrator()
 	if err != nil {
-		return 0, fmt.Errorf("failed to get like count: %w", err)
+		return fmt.Errorf("failed to load migrations: %w", err)
 	}
 
-	return count, nil
-}
-
-func (db *DB) IsPostLiked(userID, postID int) (bool, error) {
-	query := `SELECT COUNT(*) FROM likes WHERE user_id = ? AND post_id = ?`
-
-	var count int
-	err := db.conn.QueryRow(query, userID, postID).Scan(&count)
-	if err != nil {
-		return false, fmt.Errorf("failed to check like status: %w", err)
+	if err := m.Up(); err != nil {
+		slog.Error("Failed to run migrations", "error", err)
+		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	return count > 0, nil
+	slog.Info("Database migrations completed successfully")
+	return nil
 }
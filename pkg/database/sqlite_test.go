@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestOpenPragmas(t *testing.T) {
+	db, err := Open(Config{DataDir: t.TempDir(), ReadConns: 2})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	tests := []struct {
+		pool, pragma, want string
+	}{
+		{"write", "journal_mode", "wal"},
+		{"write", "foreign_keys", "1"},
+		{"write", "synchronous", "1"},
+		{"write", "busy_timeout", "5000"},
+		{"write", "cache_size", "-16384"},
+		{"write", "query_only", "0"},
+		{"read", "journal_mode", "wal"},
+		{"read", "foreign_keys", "1"},
+		{"read", "query_only", "1"},
+	}
+	for _, tt := range tests {
+		conn := db.conn
+		if tt.pool == "read" {
+			conn = db.reader
+		}
+		var got string
+		if err := conn.QueryRowContext(ctx, "PRAGMA "+tt.pragma).Scan(&got); err != nil {
+			t.Fatalf("PRAGMA %s error = %v", tt.pragma, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s pool %s = %s, want %s", tt.pool, tt.pragma, got, tt.want)
+		}
+	}
+
+	if got := db.reader.Stats().MaxOpenConnections; got != 2 {
+		t.Errorf("read pool MaxOpenConnections = %d, want 2", got)
+	}
+	if _, err := db.reader.ExecContext(ctx, "CREATE TABLE t (id INTEGER)"); err == nil {
+		t.Errorf("write through the read pool succeeded, want query_only error")
+	}
+}
+
+func TestOpenRejectsInvalidConfig(t *testing.T) {
+	for _, config := range []Config{
+		{JournalMode: "wal); DROP TABLE users; --"},
+		{Synchronous: "sometimes"},
+		{ReadConns: -1},
+	} {
+		config.DataDir = t.TempDir()
+		if db, err := Open(config); err == nil {
+			db.Close()
+			t.Errorf("Open(%+v) error = nil", config)
+		}
+	}
+}
+
+func TestForeignKeysEnforced(t *testing.T) {
+	db := newMigratedDB(t)
+	_, err := db.Posts().Create(context.Background(), 42, "orphan")
+	if !errors.Is(err, ErrConstraint) {
+		t.Errorf("Posts().Create() for a missing user error = %v, want ErrConstraint", err)
+	}
+}
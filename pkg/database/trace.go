@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"unicode"
+
+	"github.com/dunamismax/go-stdlib/pkg/tracing"
+)
+
+// tracedConnector opens connections that record each query as a client
+// span when the query's context carries a recording span.
+type tracedConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c tracedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return tracedConn{conn}, nil
+}
+
+func (c tracedConnector) Driver() driver.Driver { return c.driver }
+
+// tracedConn forwards to the driver's connection. Returning driver.ErrSkip
+// where the driver lacks an optional interface lets database/sql fall back
+// as it would without the wrapper.
+type tracedConn struct {
+	driver.Conn
+}
+
+func (c tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+	rows, err := q.QueryContext(ctx, query, args)
+	span.SetError(err)
+	return rows, err
+}
+
+func (c tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+	result, err := e.ExecContext(ctx, query, args)
+	span.SetError(err)
+	return result, err
+}
+
+func (c tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c tracedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c tracedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c tracedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c tracedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// startQuerySpan names the span after the statement's verb, e.g. "SELECT",
+// keeping span names low-cardinality while the text goes in an attribute.
+func startQuerySpan(ctx context.Context, query string) (context.Context, *tracing.Span) {
+	if !tracing.SpanFromContext(ctx).IsRecording() {
+		return ctx, nil
+	}
+	verb := strings.TrimSpace(query)
+	if i := strings.IndexFunc(verb, unicode.IsSpace); i >= 0 {
+		verb = verb[:i]
+	}
+	return tracing.StartClient(ctx, strings.ToUpper(verb),
+		tracing.String("db.system.name", "sqlite"),
+		tracing.String("db.query.text", query),
+	)
+}
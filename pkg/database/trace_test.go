@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/dunamismax/go-stdlib/pkg/tracing"
+)
+
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []tracing.SpanData
+}
+
+func (r *spanRecorder) ExportSpans(ctx context.Context, spans []tracing.SpanData) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func (r *spanRecorder) Shutdown(ctx context.Context) error { return nil }
+
+func TestQueriesAreTraced(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	rec := &spanRecorder{}
+	tracer, err := tracing.New(tracing.Config{Exporter: rec})
+	if err != nil {
+		t.Fatalf("tracing.New() error = %v", err)
+	}
+
+	// Untraced contexts produce no spans
+	if _, err := db.Users().Create(context.Background(), "gopher", "gopher@example.com", "", "hash"); err != nil {
+		t.Fatalf("Users().Create() error = %v", err)
+	}
+
+	tracing.SetDefault(tracer)
+	defer tracing.SetDefault(nil)
+	ctx, root := tracing.Start(context.Background(), "request")
+	if _, err := db.Users().GetByUsername(ctx, "gopher"); err != nil {
+		t.Fatalf("GetByUsername() error = %v", err)
+	}
+	if _, err := db.Users().GetByUsername(ctx, "nobody"); err == nil {
+		t.Fatalf("GetByUsername() for a missing user error = nil")
+	}
+	root.End()
+	tracer.Shutdown(context.Background())
+
+	if len(rec.spans) != 3 {
+		t.Fatalf("exported %d spans, want 2 queries and the root", len(rec.spans))
+	}
+	for _, s := range rec.spans[:2] {
+		if s.Name != "SELECT" || s.Kind != tracing.SpanKindClient || s.Parent != root.SpanContext().SpanID {
+			t.Errorf("query span = %+v, want a SELECT client span under the root", s)
+		}
+	}
+}
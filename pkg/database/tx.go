@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Tx is the Store passed to WithTx. Its repositories run inside the
+// transaction and must not be used after the callback returns.
+type Tx struct {
+	store
+}
+
+// Busy retries back off from busyBackoff, doubling each attempt.
+const (
+	busyRetries = 5
+	busyBackoff = 10 * time.Millisecond
+)
+
+// WithTx runs fn in a transaction, committing when it returns nil and rolling
+// back when it returns an error or panics. The transaction begins IMMEDIATE,
+// so it holds SQLite's write lock from the start; if the database stays
+// busy, the whole transaction is retried a few times, which means fn may run
+// more than once and should have no effects outside tx.
+func (db *DB) WithTx(ctx context.Context, fn func(tx Tx) error) error {
+	delay := busyBackoff
+	for attempt := 0; ; attempt++ {
+		err := db.runTx(ctx, fn)
+		if !isBusy(err) || attempt == busyRetries {
+			return err
+		}
+
+		select {
+		case <-time.After(delay):
+			delay *= 2
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		}
+	}
+}
+
+func (db *DB) runTx(ctx context.Context, fn func(tx Tx) error) (err error) {
+	sqlTx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			sqlTx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			if rbErr := sqlTx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+				err = errors.Join(err, fmt.Errorf("failed to roll back transaction: %w", rbErr))
+			}
+		}
+	}()
+
+	if err := fn(Tx{store{read: sqlTx, write: sqlTx}}); err != nil {
+		return err
+	}
+	if err := sqlTx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isBusy reports whether err is SQLITE_BUSY or one of its extended codes.
+func isBusy(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code()&0xff == sqlite3.SQLITE_BUSY
+}
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newMigratedDB(t *testing.T) *DB {
+	t.Helper()
+	db := newTestDB(t)
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	return db
+}
+
+func countUsers(t *testing.T, s Store) int {
+	t.Helper()
+	n := 0
+	for _, name := range []string{"ann", "bob"} {
+		if _, err := s.Users().GetByUsername(context.Background(), name); err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+func TestWithTx(t *testing.T) {
+	ctx := context.Background()
+	boom := errors.New("boom")
+
+	tests := []struct {
+		name    string
+		fn      func(tx Tx) error
+		wantErr error
+		want    int
+	}{
+		{"commit", func(tx Tx) error {
+			if _, err := tx.Users().Create(ctx, "ann", "ann@example.com", "", "h"); err != nil {
+				return err
+			}
+			_, err := tx.Users().Create(ctx, "bob", "bob@example.com", "", "h")
+			return err
+		}, nil, 2},
+		{"rollback on error", func(tx Tx) error {
+			if _, err := tx.Users().Create(ctx, "ann", "ann@example.com", "", "h"); err != nil {
+				return err
+			}
+			return boom
+		}, boom, 0},
+		{"rollback on constraint", func(tx Tx) error {
+			if _, err := tx.Users().Create(ctx, "ann", "ann@example.com", "", "h"); err != nil {
+				return err
+			}
+			_, err := tx.Users().Create(ctx, "bob", "ann@example.com", "", "h")
+			return err
+		}, ErrConflict, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newMigratedDB(t)
+			if err := db.WithTx(ctx, tt.fn); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WithTx() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := countUsers(t, db); got != tt.want {
+				t.Errorf("users after WithTx() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithTxPanic(t *testing.T) {
+	db := newMigratedDB(t)
+	ctx := context.Background()
+
+	func() {
+		defer func() {
+			if recover() != "boom" {
+				t.Errorf("WithTx() did not re-panic")
+			}
+		}()
+		db.WithTx(ctx, func(tx Tx) error {
+			tx.Users().Create(ctx, "ann", "ann@example.com", "", "h")
+			panic("boom")
+		})
+	}()
+
+	if got := countUsers(t, db); got != 0 {
+		t.Errorf("users after panic = %d, want 0", got)
+	}
+	// The connection went back to the pool usable
+	if err := db.WithTx(ctx, func(tx Tx) error {
+		_, err := tx.Users().Create(ctx, "ann", "ann@example.com", "", "h")
+		return err
+	}); err != nil {
+		t.Errorf("WithTx() after panic error = %v", err)
+	}
+}
+
+func TestWithTxRetriesBusy(t *testing.T) {
+	dir := t.TempDir()
+	open := func() *DB {
+		// A short busy timeout so BEGIN fails instead of waiting
+		db, err := Open(Config{DataDir: dir, BusyTimeout: time.Millisecond})
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+	first, second := open(), open()
+	if err := first.Migrate(); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	ctx := context.Background()
+
+	// Hold the write lock through the first handle for a moment
+	locked := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- first.WithTx(ctx, func(tx Tx) error {
+			tx.Users().Create(ctx, "ann", "ann@example.com", "", "h")
+			close(locked)
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		})
+	}()
+	<-locked
+
+	// BEGIN IMMEDIATE fails with SQLITE_BUSY until the first commits
+	err := second.WithTx(ctx, func(tx Tx) error {
+		_, err := tx.Users().Create(ctx, "bob", "bob@example.com", "", "h")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WithTx() while locked error = %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("locking WithTx() error = %v", err)
+	}
+	if got := countUsers(t, second); got != 2 {
+		t.Errorf("users = %d, want 2", got)
+	}
+}
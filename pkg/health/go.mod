@@ -0,0 +1,3 @@
+module github.com/dunamismax/go-stdlib/pkg/health
+
+go 1.24
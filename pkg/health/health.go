@@ -0,0 +1,214 @@
+// Package health serves the probes orchestrators use to decide whether to
+// restart a process (/healthz), route traffic to it (/readyz) and which build
+// is running (/version).
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// ErrDraining is returned by ReadyCheck while the server shuts down.
+var ErrDraining = errors.New("server is shutting down")
+
+// Check reports a dependency's health. It must return once ctx is done.
+type Check func(ctx context.Context) error
+
+// Config configures New.
+type Config struct {
+	// Timeout bounds each readiness check. Default 2s.
+	Timeout time.Duration
+}
+
+// Health holds the readiness checks and serves the probe endpoints.
+type Health struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []namedCheck
+
+	buildOnce sync.Once
+	build     BuildInfo
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// CheckResult is one check's outcome in the /readyz response.
+type CheckResult struct {
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Report is the /readyz response body.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// BuildInfo is the /version response body, read from debug.ReadBuildInfo.
+type BuildInfo struct {
+	Path      string `json:"path,omitempty"`
+	Version   string `json:"version,omitempty"`
+	GoVersion string `json:"go_version"`
+	Revision  string `json:"revision,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+}
+
+func New(config Config) *Health {
+	if config.Timeout == 0 {
+		config.Timeout = 2 * time.Second
+	}
+	return &Health{timeout: config.Timeout}
+}
+
+// AddCheck adds a readiness check. Checks may be added while serving, such
+// as ReadyCheck once the server exists.
+func (h *Health) AddCheck(name string, check Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+}
+
+// ReadyCheck fails with ErrDraining once ready reports false, so pass
+// server.Server.Ready to take the process out of rotation while it drains.
+func ReadyCheck(ready func() bool) Check {
+	return func(ctx context.Context) error {
+		if !ready() {
+			return ErrDraining
+		}
+		return nil
+	}
+}
+
+// Register adds GET /healthz, /readyz and /version to mux.
+func (h *Health) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /healthz", h.Live)
+	mux.HandleFunc("GET /readyz", h.Ready)
+	mux.HandleFunc("GET /version", h.Version)
+}
+
+// Live answers 200 while the process can serve HTTP at all. It checks no
+// dependencies, so a database outage does not get the process restarted.
+func (h *Health) Live(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, Report{Status: "ok"})
+}
+
+// Ready runs every check concurrently, each bounded by the timeout, and
+// answers 200 when all pass and 503 otherwise.
+func (h *Health) Ready(w http.ResponseWriter, r *http.Request) {
+	report := h.Run(r.Context())
+	status := http.StatusOK
+	if report.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
+
+// Run executes the checks and returns their combined report.
+func (h *Health) Run(ctx context.Context) Report {
+	h.mu.RLock()
+	checks := h.checks
+	h.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = h.runCheck(ctx, c.check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: "ok", Checks: make(map[string]CheckResult, len(checks))}
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+		if results[i].Status != "ok" {
+			report.Status = "unavailable"
+		}
+	}
+	return report
+}
+
+func (h *Health) runCheck(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- errors.New("check panicked")
+			}
+		}()
+		done <- check(ctx)
+	}()
+
+	// Don't wait on a check that ignores its context
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := CheckResult{Status: "ok", Duration: time.Since(start).Round(time.Microsecond).String()}
+	if err != nil {
+		result.Status = "fail"
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Version answers with the module path, version and VCS details embedded by
+// the Go toolchain.
+func (h *Health) Version(w http.ResponseWriter, r *http.Request) {
+	h.buildOnce.Do(func() {
+		h.build = readBuildInfo()
+	})
+	writeJSON(w, http.StatusOK, h.build)
+}
+
+func readBuildInfo() BuildInfo {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return BuildInfo{GoVersion: "unknown"}
+	}
+
+	build := BuildInfo{
+		Path:      info.Main.Path,
+		Version:   info.Main.Version,
+		GoVersion: info.GoVersion,
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			build.Revision = s.Value
+		case "vcs.time":
+			build.Time = s.Value
+		case "vcs.modified":
+			build.Modified = s.Value == "true"
+		}
+	}
+	return build
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	// Probes must always see the current state
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
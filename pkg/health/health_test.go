@@ -0,0 +1,93 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func get(t *testing.T, mux *http.ServeMux, path string, v any) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	if err := json.NewDecoder(w.Body).Decode(v); err != nil {
+		t.Fatalf("GET %s: invalid JSON: %v", path, err)
+	}
+	return w.Code
+}
+
+func TestReadiness(t *testing.T) {
+	var ready atomic.Bool
+	ready.Store(true)
+	dbErr := error(nil)
+
+	h := New(Config{Timeout: 50 * time.Millisecond})
+	h.AddCheck("database", func(ctx context.Context) error { return dbErr })
+	h.AddCheck("server", ReadyCheck(ready.Load))
+	mux := http.NewServeMux()
+	h.Register(mux)
+
+	tests := []struct {
+		name       string
+		setup      func()
+		wantStatus int
+		wantFailed string
+	}{
+		{name: "all passing", setup: func() {}, wantStatus: http.StatusOK},
+		{name: "database down", setup: func() { dbErr = errors.New("disk I/O error") }, wantStatus: http.StatusServiceUnavailable, wantFailed: "database"},
+		{name: "draining", setup: func() { dbErr = nil; ready.Store(false) }, wantStatus: http.StatusServiceUnavailable, wantFailed: "server"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			var report Report
+			if code := get(t, mux, "/readyz", &report); code != tt.wantStatus {
+				t.Errorf("GET /readyz status = %d, want %d", code, tt.wantStatus)
+			}
+			for name, result := range report.Checks {
+				if failed := result.Status != "ok"; failed != (name == tt.wantFailed) {
+					t.Errorf("check %s = %+v, want failed only for %q", name, result, tt.wantFailed)
+				}
+			}
+		})
+	}
+
+	// Liveness ignores dependencies
+	var live Report
+	if code := get(t, mux, "/healthz", &live); code != http.StatusOK || live.Status != "ok" {
+		t.Errorf("GET /healthz = %d %+v, want 200 ok", code, live)
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	h := New(Config{Timeout: 20 * time.Millisecond})
+	h.AddCheck("stuck", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	start := time.Now()
+	report := h.Run(context.Background())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Run() took %v, want it bounded by the timeout", elapsed)
+	}
+	if got := report.Checks["stuck"]; got.Status != "fail" || got.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("stuck check = %+v, want a deadline failure", got)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	mux := http.NewServeMux()
+	New(Config{}).Register(mux)
+
+	var build BuildInfo
+	if code := get(t, mux, "/version", &build); code != http.StatusOK || build.GoVersion == "" {
+		t.Errorf("GET /version = %d %+v, want 200 with a Go version", code, build)
+	}
+}
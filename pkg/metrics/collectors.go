@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"database/sql"
+	"runtime"
+	"time"
+)
+
+// RegisterRuntime adds Go runtime metrics: goroutines, memory, garbage
+// collection and the process start time. Memory stats are read once per
+// scrape.
+func RegisterRuntime(r *Registry) {
+	goroutines := r.NewGauge("go_goroutines", "Number of goroutines that currently exist.")
+	alloc := r.NewGauge("go_memstats_alloc_bytes", "Bytes of allocated heap objects.")
+	heapInuse := r.NewGauge("go_memstats_heap_inuse_bytes", "Bytes in in-use heap spans.")
+	sys := r.NewGauge("go_memstats_sys_bytes", "Bytes of memory obtained from the OS.")
+	gcCycles := r.NewCounter("go_gc_cycles_total", "Completed garbage collection cycles.")
+	gcPause := r.NewCounter("go_gc_pause_seconds_total", "Total time spent in stop-the-world GC pauses.")
+
+	r.NewGauge("go_info", "Information about the Go environment.", "version").Set(1, runtime.Version())
+	r.NewGauge("process_start_time_seconds", "Start time of the process since the Unix epoch in seconds.").
+		Set(float64(time.Now().Unix()))
+
+	r.OnCollect(func() {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+
+		goroutines.Set(float64(runtime.NumGoroutine()))
+		alloc.Set(float64(m.HeapAlloc))
+		heapInuse.Set(float64(m.HeapInuse))
+		sys.Set(float64(m.Sys))
+		gcCycles.set(float64(m.NumGC))
+		gcPause.set(time.Duration(m.PauseTotalNs).Seconds())
+	})
+}
+
+// RegisterDBStats adds connection pool metrics for db, labelled with name so
+// several pools can share a registry.
+func RegisterDBStats(r *Registry, name string, db *sql.DB) {
+	maxOpen := r.NewGauge("go_sql_max_open_connections", "Maximum number of open connections to the database.", "db")
+	open := r.NewGauge("go_sql_open_connections", "Established connections, both in use and idle.", "db")
+	inUse := r.NewGauge("go_sql_in_use_connections", "Connections currently in use.", "db")
+	idle := r.NewGauge("go_sql_idle_connections", "Idle connections.", "db")
+	waitCount := r.NewCounter("go_sql_wait_count_total", "Connections waited for.", "db")
+	waitDuration := r.NewCounter("go_sql_wait_duration_seconds_total", "Time blocked waiting for a new connection.", "db")
+	idleClosed := r.NewCounter("go_sql_max_idle_closed_total", "Connections closed due to SetMaxIdleConns.", "db")
+	lifetimeClosed := r.NewCounter("go_sql_max_lifetime_closed_total", "Connections closed due to SetConnMaxLifetime.", "db")
+
+	r.OnCollect(func() {
+		s := db.Stats()
+		maxOpen.Set(float64(s.MaxOpenConnections), name)
+		open.Set(float64(s.OpenConnections), name)
+		inUse.Set(float64(s.InUse), name)
+		idle.Set(float64(s.Idle), name)
+		waitCount.set(float64(s.WaitCount), name)
+		waitDuration.set(s.WaitDuration.Seconds(), name)
+		idleClosed.set(float64(s.MaxIdleClosed), name)
+		lifetimeClosed.set(float64(s.MaxLifetimeClosed), name)
+	})
+}
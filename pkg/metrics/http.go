@@ -0,0 +1,166 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dunamismax/go-stdlib/pkg/middleware"
+)
+
+// unmatchedRoute labels requests no route claimed, so scanners probing
+// random paths cannot create unbounded series.
+const unmatchedRoute = "unmatched"
+
+type routeContextKey struct{}
+
+// HTTPMetrics records request counts, latencies and in-flight requests.
+type HTTPMetrics struct {
+	requests *Counter
+	duration *Histogram
+	inFlight *Gauge
+
+	// Totals and a one-minute window for Snapshot
+	current atomic.Int64
+	total   atomic.Uint64
+	nanos   atomic.Int64
+	window  rollingCounter
+}
+
+// HTTPSnapshot is a summary for display in the application itself.
+type HTTPSnapshot struct {
+	InFlight           int64
+	Requests           uint64
+	RequestsLastMinute uint64
+	MeanLatency        time.Duration
+}
+
+func NewHTTPMetrics(r *Registry) *HTTPMetrics {
+	return &HTTPMetrics{
+		requests: r.NewCounter("http_requests_total", "HTTP requests by method, route and status.", "method", "route", "status"),
+		duration: r.NewHistogram("http_request_duration_seconds", "HTTP request latency by method and route.", nil, "method", "route"),
+		inFlight: r.NewGauge("http_requests_in_flight", "HTTP requests currently being served."),
+	}
+}
+
+// Middleware records every request. The route label comes from SetRoute
+// when a router inside calls it, otherwise from route, which may be nil;
+// requests neither names are labelled "unmatched".
+func (m *HTTPMetrics) Middleware(route func(r *http.Request) string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			m.inFlight.Inc()
+			m.current.Add(1)
+
+			var name string
+			r = r.WithContext(context.WithValue(r.Context(), routeContextKey{}, &name))
+			sw := middleware.WrapStatusWriter(w)
+			defer func() {
+				m.inFlight.Dec()
+				m.current.Add(-1)
+				if name == "" && route != nil {
+					name = route(r)
+				}
+				m.Observe(r.Method, name, sw.Status(), time.Since(start))
+			}()
+
+			next.ServeHTTP(sw, r)
+		})
+	}
+}
+
+// Observe records one finished request. Routers that cannot use Middleware
+// call it directly.
+func (m *HTTPMetrics) Observe(method, route string, status int, d time.Duration) {
+	method = normalizeMethod(method)
+	if route == "" {
+		route = unmatchedRoute
+	}
+
+	m.requests.Inc(method, route, strconv.Itoa(status))
+	m.duration.Observe(d.Seconds(), method, route)
+
+	m.total.Add(1)
+	m.nanos.Add(int64(d))
+	m.window.inc(time.Now())
+}
+
+// Snapshot returns current totals for the application's own pages.
+func (m *HTTPMetrics) Snapshot() HTTPSnapshot {
+	s := HTTPSnapshot{
+		InFlight:           m.current.Load(),
+		Requests:           m.total.Load(),
+		RequestsLastMinute: m.window.sum(time.Now()),
+	}
+	if s.Requests > 0 {
+		s.MeanLatency = time.Duration(m.nanos.Load() / int64(s.Requests))
+	}
+	return s
+}
+
+// SetRoute names the route serving r, for routers whose patterns Middleware
+// cannot see, such as Echo's c.Path(). It is a no-op outside Middleware.
+func SetRoute(r *http.Request, route string) {
+	if name, ok := r.Context().Value(routeContextKey{}).(*string); ok {
+		*name = route
+	}
+}
+
+// MuxRoute labels requests with the mux pattern that matches them, without
+// the method prefix since method is its own label.
+func MuxRoute(mux *http.ServeMux) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		_, pattern := mux.Handler(r)
+		if _, path, ok := strings.Cut(pattern, " "); ok {
+			return path
+		}
+		return pattern
+	}
+}
+
+func normalizeMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
+
+// rollingCounter counts events over the last minute in one-second slots.
+type rollingCounter struct {
+	mu    sync.Mutex
+	slots [60]struct {
+		second int64
+		count  uint64
+	}
+}
+
+func (c *rollingCounter) inc(now time.Time) {
+	sec := now.Unix()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	slot := &c.slots[sec%60]
+	if slot.second != sec {
+		slot.second, slot.count = sec, 0
+	}
+	slot.count++
+}
+
+func (c *rollingCounter) sum(now time.Time) uint64 {
+	sec := now.Unix()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var total uint64
+	for _, slot := range c.slots {
+		if sec-slot.second < 60 {
+			total += slot.count
+		}
+	}
+	return total
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	r := NewRegistry()
+	m := NewHTTPMetrics(r)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /posts/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("POST /posts", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("GET /custom", func(w http.ResponseWriter, r *http.Request) {
+		SetRoute(r, "/custom/{name}")
+	})
+	handler := m.Middleware(MuxRoute(mux))(mux)
+
+	for _, req := range []struct{ method, path string }{
+		{http.MethodGet, "/posts/1"},
+		{http.MethodGet, "/posts/2"},
+		{http.MethodPost, "/posts"},
+		{http.MethodGet, "/custom"},
+		{http.MethodGet, "/wp-login.php"},
+		{"PROPFIND", "/posts/1"},
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	tests := []struct {
+		labels []string
+		want   float64
+	}{
+		{[]string{"GET", "/posts/{id}", "200"}, 2},
+		{[]string{"POST", "/posts", "201"}, 1},
+		{[]string{"GET", "/custom/{name}", "200"}, 1},
+		{[]string{"GET", "unmatched", "404"}, 1},
+		{[]string{"OTHER", "unmatched", "405"}, 1},
+	}
+	for _, tt := range tests {
+		if got := m.requests.Value(tt.labels...); got != tt.want {
+			t.Errorf("http_requests_total%v = %v, want %v", tt.labels, got, tt.want)
+		}
+	}
+
+	snap := m.Snapshot()
+	if snap.Requests != 6 || snap.RequestsLastMinute != 6 || snap.InFlight != 0 {
+		t.Errorf("Snapshot() = %+v, want 6 requests and none in flight", snap)
+	}
+
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(w.Body.String(), `http_request_duration_seconds_count{method="GET",route="/posts/{id}"} 2`) {
+		t.Errorf("scrape missing latency histogram:\n%s", w.Body.String())
+	}
+}
+
+func TestRollingCounter(t *testing.T) {
+	var c rollingCounter
+	start := time.Unix(1000, 0)
+	c.inc(start)
+	c.inc(start.Add(30 * time.Second))
+	c.inc(start.Add(59 * time.Second))
+
+	if got := c.sum(start.Add(59 * time.Second)); got != 3 {
+		t.Errorf("sum() within a minute = %d, want 3", got)
+	}
+	if got := c.sum(start.Add(61 * time.Second)); got != 2 {
+		t.Errorf("sum() after the first expired = %d, want 2", got)
+	}
+	// A slot reused a minute later starts over
+	c.inc(start.Add(60 * time.Second))
+	if got := c.sum(start.Add(60 * time.Second)); got != 3 {
+		t.Errorf("sum() after slot reuse = %d, want 3", got)
+	}
+}
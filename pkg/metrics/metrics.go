@@ -0,0 +1,369 @@
+// Package metrics keeps counters, gauges and histograms in memory and
+// exposes them in the Prometheus text format, without pulling in the
+// Prometheus client library.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultBuckets suit request latencies in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+var namePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+type kind string
+
+const (
+	counterKind   kind = "counter"
+	gaugeKind     kind = "gauge"
+	histogramKind kind = "histogram"
+)
+
+// Registry holds metric families and renders them for scraping.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+	hooks    []func()
+
+	// collectMu serialises scrapes so OnCollect hooks never interleave
+	collectMu sync.Mutex
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// NewCounter registers a counter with the given label names. Registering the
+// same name again with the same labels returns the existing counter, so
+// independent components can share a metric.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{r.register(name, help, counterKind, labels, nil)}
+}
+
+// NewGauge registers a gauge; see NewCounter.
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{r.register(name, help, gaugeKind, labels, nil)}
+}
+
+// NewHistogram registers a histogram with upper bucket bounds, or
+// DefaultBuckets when buckets is nil; see NewCounter.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	return &Histogram{r.register(name, help, histogramKind, labels, buckets)}
+}
+
+// OnCollect registers fn to run before every scrape, to refresh metrics
+// that are read from elsewhere such as runtime or connection pool stats.
+func (r *Registry) OnCollect(fn func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, fn)
+}
+
+// register panics on invalid or conflicting definitions: both are
+// programming errors best caught at startup.
+func (r *Registry) register(name, help string, k kind, labels []string, buckets []float64) *family {
+	if !namePattern.MatchString(name) {
+		panic(fmt.Sprintf("metrics: invalid metric name %q", name))
+	}
+	for _, label := range labels {
+		if !namePattern.MatchString(label) || strings.HasPrefix(label, "__") || label == "le" {
+			panic(fmt.Sprintf("metrics: invalid label name %q for %s", label, name))
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.families[name]; ok {
+		if f.kind != k || !slices.Equal(f.labels, labels) || !slices.Equal(f.buckets, buckets) {
+			panic(fmt.Sprintf("metrics: %s already registered with a different type or labels", name))
+		}
+		return f
+	}
+
+	f := &family{
+		name:    name,
+		help:    help,
+		kind:    k,
+		labels:  slices.Clone(labels),
+		buckets: buckets,
+		series:  make(map[string]*series),
+	}
+	r.families[name] = f
+	return f
+}
+
+// Handler serves the registry in the Prometheus text format.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		r.WriteTo(w)
+	})
+}
+
+// WriteTo runs the OnCollect hooks and writes every family to w, sorted by
+// name, in the Prometheus text format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.collectMu.Lock()
+	defer r.collectMu.Unlock()
+
+	r.mu.Lock()
+	hooks := slices.Clone(r.hooks)
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook()
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	for _, f := range families {
+		f.write(cw)
+	}
+	if err := cw.w.Flush(); err != nil && cw.err == nil {
+		cw.err = err
+	}
+	return cw.n, cw.err
+}
+
+type family struct {
+	name    string
+	help    string
+	kind    kind
+	labels  []string
+	buckets []float64
+
+	mu     sync.RWMutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       atomicFloat
+	// Histograms only: per-bucket (not cumulative) counts, sum and count
+	bucketCounts []atomic.Uint64
+	count        atomic.Uint64
+}
+
+// with returns the series for labelValues, creating it on first use.
+func (f *family) with(labelValues []string) *series {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+
+	f.mu.RLock()
+	s, ok := f.series[key]
+	f.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if s, ok := f.series[key]; ok {
+		return s
+	}
+	s = &series{labelValues: slices.Clone(labelValues)}
+	if f.kind == histogramKind {
+		s.bucketCounts = make([]atomic.Uint64, len(f.buckets))
+	}
+	f.series[key] = s
+	return s
+}
+
+func (f *family) write(w *countingWriter) {
+	f.mu.RLock()
+	all := make([]*series, 0, len(f.series))
+	for _, s := range f.series {
+		all = append(all, s)
+	}
+	f.mu.RUnlock()
+	if len(all) == 0 {
+		return
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return slices.Compare(all[i].labelValues, all[j].labelValues) < 0
+	})
+
+	if f.help != "" {
+		w.printf("# HELP %s %s\n", f.name, escapeHelp(f.help))
+	}
+	w.printf("# TYPE %s %s\n", f.name, f.kind)
+
+	for _, s := range all {
+		labels := formatLabels(f.labels, s.labelValues)
+		if f.kind != histogramKind {
+			w.printf("%s%s %s\n", f.name, wrapLabels(labels), formatFloat(s.value.load()))
+			continue
+		}
+
+		var cumulative uint64
+		for i, bound := range f.buckets {
+			cumulative += s.bucketCounts[i].Load()
+			w.printf("%s_bucket%s %d\n", f.name, wrapLabels(joinLabels(labels, `le="`+formatFloat(bound)+`"`)), cumulative)
+		}
+		count := s.count.Load()
+		w.printf("%s_bucket%s %d\n", f.name, wrapLabels(joinLabels(labels, `le="+Inf"`)), count)
+		w.printf("%s_sum%s %s\n", f.name, wrapLabels(labels), formatFloat(s.value.load()))
+		w.printf("%s_count%s %d\n", f.name, wrapLabels(labels), count)
+	}
+}
+
+// Counter is a value that only goes up, optionally split by labels.
+type Counter struct{ f *family }
+
+// Inc adds 1 to the series for labelValues.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which must not be negative.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic(fmt.Sprintf("metrics: counter %s cannot decrease", c.f.name))
+	}
+	c.f.with(labelValues).value.add(v)
+}
+
+// Value returns the current value for labelValues.
+func (c *Counter) Value(labelValues ...string) float64 {
+	return c.f.with(labelValues).value.load()
+}
+
+// set mirrors a total kept elsewhere, such as sql.DBStats.WaitCount.
+func (c *Counter) set(v float64, labelValues ...string) {
+	c.f.with(labelValues).value.store(v)
+}
+
+// Gauge is a value that can go up and down.
+type Gauge struct{ f *family }
+
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.f.with(labelValues).value.store(v)
+}
+
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.f.with(labelValues).value.add(v)
+}
+
+func (g *Gauge) Inc(labelValues ...string) { g.Add(1, labelValues...) }
+
+func (g *Gauge) Dec(labelValues ...string) { g.Add(-1, labelValues...) }
+
+func (g *Gauge) Value(labelValues ...string) float64 {
+	return g.f.with(labelValues).value.load()
+}
+
+// Histogram counts observations into buckets.
+type Histogram struct{ f *family }
+
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	s := h.f.with(labelValues)
+	if i, _ := slices.BinarySearch(h.f.buckets, v); i < len(h.f.buckets) {
+		s.bucketCounts[i].Add(1)
+	}
+	s.value.add(v)
+	s.count.Add(1)
+}
+
+// Count and Sum return the number and total of observations for labelValues.
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	return h.f.with(labelValues).count.Load()
+}
+
+func (h *Histogram) Sum(labelValues ...string) float64 {
+	return h.f.with(labelValues).value.load()
+}
+
+type atomicFloat struct{ bits atomic.Uint64 }
+
+func (a *atomicFloat) load() float64 { return math.Float64frombits(a.bits.Load()) }
+
+func (a *atomicFloat) store(v float64) { a.bits.Store(math.Float64bits(v)) }
+
+func (a *atomicFloat) add(v float64) {
+	for {
+		old := a.bits.Load()
+		if a.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string { return helpEscaper.Replace(s) }
+
+func formatLabels(names, values []string) string {
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + labelEscaper.Replace(values[i]) + `"`
+	}
+	return strings.Join(pairs, ",")
+}
+
+func joinLabels(labels, extra string) string {
+	if labels == "" {
+		return extra
+	}
+	return labels + "," + extra
+}
+
+func wrapLabels(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) printf(format string, args ...any) {
+	if c.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(c.w, format, args...)
+	c.n += int64(n)
+	c.err = err
+}
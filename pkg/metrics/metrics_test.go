@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWriteTo(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("jobs_total", "Jobs processed.", "queue")
+	g := r.NewGauge("temperature", "Line one\nline two.")
+	h := r.NewHistogram("latency_seconds", "", []float64{0.5, 0.1}, "op")
+
+	c.Inc("mail")
+	c.Add(2, `say "hi"`)
+	g.Set(-1.5)
+	h.Observe(0.05, "read")
+	h.Observe(0.1, "read")
+	h.Observe(3, "read")
+
+	var out strings.Builder
+	if _, err := r.WriteTo(&out); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+
+	want := `# HELP jobs_total Jobs processed.
+# TYPE jobs_total counter
+jobs_total{queue="mail"} 1
+jobs_total{queue="say \"hi\""} 2
+# TYPE latency_seconds histogram
+latency_seconds_bucket{op="read",le="0.1"} 2
+latency_seconds_bucket{op="read",le="0.5"} 2
+latency_seconds_bucket{op="read",le="+Inf"} 3
+latency_seconds_sum{op="read"} 3.15
+latency_seconds_count{op="read"} 3
+# HELP temperature Line one\nline two.
+# TYPE temperature gauge
+temperature -1.5
+`
+	if out.String() != want {
+		t.Errorf("WriteTo() =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestRegisterSharesAndRejects(t *testing.T) {
+	r := NewRegistry()
+	a := r.NewCounter("hits_total", "", "path")
+	b := r.NewCounter("hits_total", "", "path")
+	a.Inc("/")
+	if got := b.Value("/"); got != 1 {
+		t.Errorf("re-registered counter Value() = %v, want 1", got)
+	}
+
+	for name, register := range map[string]func(){
+		"type conflict":  func() { r.NewGauge("hits_total", "", "path") },
+		"label conflict": func() { r.NewCounter("hits_total", "", "route") },
+		"invalid name":   func() { r.NewCounter("hits-total", "") },
+		"reserved label": func() { r.NewHistogram("h", "", nil, "le") },
+		"wrong arity":    func() { a.Inc() },
+		"negative add":   func() { a.Add(-1, "/") },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s did not panic", name)
+				}
+			}()
+			register()
+		})
+	}
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("n_total", "", "k")
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				c.Add(0.5, "x")
+			}
+		}()
+	}
+	wg.Wait()
+	if got := c.Value("x"); got != 2500 {
+		t.Errorf("Value() = %v, want 2500", got)
+	}
+}
+
+// nopConnector backs a *sql.DB that is never queried; Stats needs no driver.
+type nopConnector struct{}
+
+func (nopConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (nopConnector) Driver() driver.Driver { return nil }
+
+func TestCollectors(t *testing.T) {
+	r := NewRegistry()
+	RegisterRuntime(r)
+	RegisterDBStats(r, "main", sql.OpenDB(nopConnector{}))
+
+	var out strings.Builder
+	r.WriteTo(&out)
+	for _, want := range []string{"go_goroutines ", "go_info{version=", `go_sql_open_connections{db="main"} 0`, "go_gc_cycles_total "} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("scrape missing %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+)
+
+type (
+	requestIDContextKey struct{}
+	loggerContextKey    struct{}
+)
+
+const maxRequestIDLength = 128
+
+// AccessLogConfig configures AccessLog. The zero value logs every request to
+// slog.Default().
+type AccessLogConfig struct {
+	// Logger receives access log lines and is the parent of each request's
+	// logger. Default slog.Default().
+	Logger *slog.Logger
+	// RequestIDHeader is read from the request and echoed on the response.
+	// Default "X-Request-ID".
+	RequestIDHeader string
+	// SampleRate is the fraction of successful (< 400) requests logged.
+	// Zero logs everything; errors are always logged.
+	SampleRate float64
+	// ExcludePaths are never logged, for health checks and static assets.
+	// A trailing "*" matches a prefix.
+	ExcludePaths []string
+}
+
+// AccessLog logs one line per request with its status, response size and
+// duration. It propagates a well-formed incoming request ID or generates one,
+// and stores a logger tagged with it in the request context for handlers
+// (see RequestLogger).
+func AccessLog(config AccessLogConfig) func(next http.Handler) http.Handler {
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+	if config.RequestIDHeader == "" {
+		config.RequestIDHeader = "X-Request-ID"
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			id := r.Header.Get(config.RequestIDHeader)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+			w.Header().Set(config.RequestIDHeader, id)
+
+			logger := config.Logger.With("request_id", id)
+			ctx := context.WithValue(r.Context(), requestIDContextKey{}, id)
+			ctx = context.WithValue(ctx, loggerContextKey{}, logger)
+			r = r.WithContext(ctx)
+
+			sw := WrapStatusWriter(w)
+			next.ServeHTTP(sw, r)
+
+			status := sw.Status()
+			if isExemptPath(r.URL.Path, config.ExcludePaths) {
+				return
+			}
+			if status < 400 && config.SampleRate > 0 && rand.Float64() >= config.SampleRate {
+				return
+			}
+
+			level := slog.LevelInfo
+			switch {
+			case status >= 500:
+				level = slog.LevelError
+			case status >= 400:
+				level = slog.LevelWarn
+			}
+
+			logger.LogAttrs(r.Context(), level, "HTTP request",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", status),
+				slog.Int64("bytes", sw.BytesWritten()),
+				slog.Duration("duration", time.Since(start)),
+				slog.String("client_ip", ClientIP(r)),
+				slog.String("user_agent", r.UserAgent()),
+			)
+		})
+	}
+}
+
+// RequestID returns the ID assigned by AccessLog, or "" without it.
+func RequestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// RequestLogger returns the request-scoped logger installed by AccessLog.
+func RequestLogger(r *http.Request) *slog.Logger {
+	return LoggerFromContext(r.Context())
+}
+
+// LoggerFromContext returns the request-scoped logger in ctx, falling back to
+// slog.Default() outside a request.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerContextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// validRequestID accepts short IDs of printable ASCII so a client cannot
+// inject log-breaking content through the header.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := cryptorand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// StatusWriter records the status code and number of body bytes written
+// while passing http.Flusher and http.Hijacker through to the underlying
+// writer.
+type StatusWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+// WrapStatusWriter wraps w, or returns it unchanged if it is already a
+// StatusWriter so stacked middleware share one recorder.
+func WrapStatusWriter(w http.ResponseWriter) *StatusWriter {
+	if sw, ok := w.(*StatusWriter); ok {
+		return sw
+	}
+	return &StatusWriter{ResponseWriter: w}
+}
+
+func (sw *StatusWriter) WriteHeader(code int) {
+	// Informational responses are not final; keep waiting for the real one
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		sw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if !sw.wroteHeader {
+		sw.status = code
+		sw.wroteHeader = true
+	}
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *StatusWriter) Write(b []byte) (int, error) {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	n, err := sw.ResponseWriter.Write(b)
+	sw.bytes += int64(n)
+	return n, err
+}
+
+// Status returns the response status, 200 if the handler wrote nothing.
+func (sw *StatusWriter) Status() int {
+	if !sw.wroteHeader {
+		return http.StatusOK
+	}
+	return sw.status
+}
+
+// BytesWritten returns the number of body bytes written.
+func (sw *StatusWriter) BytesWritten() int64 {
+	return sw.bytes
+}
+
+// WroteHeader reports whether the response has been committed.
+func (sw *StatusWriter) WroteHeader() bool {
+	return sw.wroteHeader
+}
+
+func (sw *StatusWriter) Flush() {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (sw *StatusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := sw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("underlying ResponseWriter does not implement http.Hijacker")
+	}
+	if !sw.wroteHeader {
+		sw.status = http.StatusSwitchingProtocols
+		sw.wroteHeader = true
+	}
+	return h.Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (sw *StatusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	var requestID string
+	handler := AccessLog(AccessLogConfig{Logger: logger, ExcludePaths: []string{"/healthz"}})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID = RequestID(r)
+			RequestLogger(r).Info("handler ran")
+			if _, ok := w.(http.Flusher); !ok {
+				t.Errorf("wrapped writer does not implement http.Flusher")
+			}
+			w.WriteHeader(http.StatusTeapot)
+			w.Write([]byte("short and stout"))
+		}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pot", nil))
+
+	if requestID == "" || w.Header().Get("X-Request-ID") != requestID {
+		t.Errorf("X-Request-ID = %q, want generated ID %q", w.Header().Get("X-Request-ID"), requestID)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("logged %d lines, want 2", len(lines))
+	}
+	var entry struct {
+		Level     string `json:"level"`
+		RequestID string `json:"request_id"`
+		Status    int    `json:"status"`
+		Bytes     int    `json:"bytes"`
+	}
+	json.Unmarshal([]byte(lines[1]), &entry)
+	if entry.Status != http.StatusTeapot || entry.Bytes != 15 || entry.Level != "WARN" || entry.RequestID != requestID {
+		t.Errorf("access log = %+v, want status 418, 15 bytes, WARN, request ID %q", entry, requestID)
+	}
+	if !strings.Contains(lines[0], requestID) {
+		t.Errorf("handler log %s does not carry the request ID", lines[0])
+	}
+
+	// Incoming IDs are propagated; excluded paths are not logged
+	buf.Reset()
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set("X-Request-ID", "upstream-123")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if requestID != "upstream-123" {
+		t.Errorf("RequestID() = %q, want %q", requestID, "upstream-123")
+	}
+	if strings.Contains(buf.String(), "HTTP request") {
+		t.Errorf("excluded path was logged: %s", buf.String())
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"abc-123", true},
+		{"", false},
+		{"has space", false},
+		{"line\nbreak", false},
+		{strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+
+	for _, tt := range tests {
+		if got := validRequestID(tt.id); got != tt.want {
+			t.Errorf("validRequestID(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
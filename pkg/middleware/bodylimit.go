@@ -0,0 +1,20 @@
+package middleware
+
+import "net/http"
+
+// MaxBodySize caps request bodies at limit bytes. A request declaring a
+// larger Content-Length is refused with 413 before any handler reads it;
+// other bodies fail with *http.MaxBytesError once they pass the limit.
+// Install it outside CSRF, which parses form bodies to find the token.
+func MaxBodySize(limit int64) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
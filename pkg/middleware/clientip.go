@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+type clientIPContextKey struct{}
+
+// ClientIPResolver determines the originating client address of a request.
+// Forwarding headers are only honoured when the direct peer is a trusted
+// proxy, and are walked from the right so a client cannot spoof its address
+// by prepending entries.
+type ClientIPResolver struct {
+	trusted []netip.Prefix
+}
+
+// NewClientIPResolver trusts the given proxy CIDRs or bare IPs. With none,
+// forwarding headers are ignored and the peer address is always used.
+func NewClientIPResolver(trustedProxies ...string) (*ClientIPResolver, error) {
+	cr := &ClientIPResolver{}
+	for _, s := range trustedProxies {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		if !strings.Contains(s, "/") {
+			addr, err := netip.ParseAddr(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", s, err)
+			}
+			addr = addr.Unmap()
+			s = netip.PrefixFrom(addr, addr.BitLen()).String()
+		}
+
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", s, err)
+		}
+		cr.trusted = append(cr.trusted, prefix.Masked())
+	}
+	return cr, nil
+}
+
+// ParseTrustedProxies builds a resolver from a comma-separated list, as read
+// from an environment variable.
+func ParseTrustedProxies(list string) (*ClientIPResolver, error) {
+	return NewClientIPResolver(strings.Split(list, ",")...)
+}
+
+// Middleware resolves the client IP once and stores it in the request
+// context, where ClientIP, the logger and the rate limiter read it.
+func (cr *ClientIPResolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), clientIPContextKey{}, cr.Resolve(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Resolve returns the client IP for r. The RFC 7239 Forwarded header takes
+// precedence over X-Forwarded-For, which takes precedence over X-Real-IP.
+func (cr *ClientIPResolver) Resolve(r *http.Request) string {
+	peer, ok := parseIP(remoteHost(r.RemoteAddr))
+	if !ok {
+		return remoteHost(r.RemoteAddr)
+	}
+	if !cr.isTrusted(peer) {
+		return peer.String()
+	}
+
+	var hops []string
+	if values := r.Header.Values("Forwarded"); len(values) > 0 {
+		hops = forwardedFor(values)
+	} else if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+		for _, v := range values {
+			for _, hop := range strings.Split(v, ",") {
+				hops = append(hops, forwardedNode(strings.TrimSpace(hop)))
+			}
+		}
+	} else if xri := r.Header.Get("X-Real-IP"); xri != "" {
+		hops = []string{strings.TrimSpace(xri)}
+	}
+
+	// Walk from the nearest hop outwards. The first address not belonging
+	// to a trusted proxy is the client; anything left of it is untrusted.
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip, ok := parseIP(hops[i])
+		if !ok {
+			// Obfuscated or malformed hop: the last trusted proxy is
+			// the best address available.
+			break
+		}
+		client = ip
+		if !cr.isTrusted(ip) {
+			break
+		}
+	}
+
+	return client.String()
+}
+
+func (cr *ClientIPResolver) isTrusted(ip netip.Addr) bool {
+	for _, prefix := range cr.trusted {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the client IP stored by ClientIPResolver.Middleware, or
+// the peer address when the middleware is not installed.
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPContextKey{}).(string); ok {
+		return ip
+	}
+	return remoteHost(r.RemoteAddr)
+}
+
+// forwardedFor extracts the for= parameters of RFC 7239 Forwarded header
+// values, in order.
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, v := range values {
+		for _, element := range strings.Split(v, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok || !strings.EqualFold(name, "for") {
+					continue
+				}
+				hops = append(hops, forwardedNode(value))
+			}
+		}
+	}
+	return hops
+}
+
+// forwardedNode strips quoting, brackets and port from a node such as
+// "[2001:db8::1]:4711" or 192.0.2.60.
+func forwardedNode(node string) string {
+	node = strings.Trim(node, `"`)
+	if strings.HasPrefix(node, "[") {
+		if end := strings.Index(node, "]"); end > 0 {
+			return node[1:end]
+		}
+		return node
+	}
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		return host
+	}
+	return node
+}
+
+func parseIP(s string) (netip.Addr, bool) {
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap().WithZone(""), true
+}
+
+func remoteHost(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIPResolver(t *testing.T) {
+	resolver, err := NewClientIPResolver("10.0.0.0/8", "192.0.2.1", "2001:db8::/32")
+	if err != nil {
+		t.Fatalf("NewClientIPResolver() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		remote string
+		header http.Header
+		want   string
+	}{
+		{"untrusted peer ignores headers", "203.0.113.9:1234", http.Header{"X-Forwarded-For": {"1.1.1.1"}}, "203.0.113.9"},
+		{"trusted peer without headers", "10.0.0.1:1234", nil, "10.0.0.1"},
+		{"single hop", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"198.51.100.7"}}, "198.51.100.7"},
+		{"multi hop", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"198.51.100.7, 10.1.1.1, 192.0.2.1"}}, "198.51.100.7"},
+		{"spoofed left entry", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"6.6.6.6, 198.51.100.7"}}, "198.51.100.7"},
+		{"repeated header lines", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"6.6.6.6", "198.51.100.7, 10.1.1.1"}}, "198.51.100.7"},
+		{"all trusted", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"10.2.2.2, 10.1.1.1"}}, "10.2.2.2"},
+		{"garbage hop", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"198.51.100.7, garbage"}}, "10.0.0.1"},
+		{"forwarded header", "10.0.0.1:1234", http.Header{"Forwarded": {`for=6.6.6.6, for=198.51.100.7;proto=https, for="[2001:db8::1]:4711"`}}, "198.51.100.7"},
+		{"forwarded ipv6", "10.0.0.1:1234", http.Header{"Forwarded": {`for="[2001:db9::1]:4711";by=10.0.0.1`}}, "2001:db9::1"},
+		{"forwarded obfuscated", "10.0.0.1:1234", http.Header{"Forwarded": {"for=_hidden"}}, "10.0.0.1"},
+		{"forwarded wins over xff", "10.0.0.1:1234", http.Header{"Forwarded": {"for=198.51.100.7"}, "X-Forwarded-For": {"6.6.6.6"}}, "198.51.100.7"},
+		{"x-real-ip", "10.0.0.1:1234", http.Header{"X-Real-Ip": {"198.51.100.7"}}, "198.51.100.7"},
+		{"ipv4-mapped peer", "[::ffff:192.0.2.1]:1234", http.Header{"X-Forwarded-For": {"198.51.100.7"}}, "198.51.100.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			req.Header = tt.header
+			if req.Header == nil {
+				req.Header = http.Header{}
+			}
+
+			if got := resolver.Resolve(req); got != tt.want {
+				t.Errorf("Resolve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientIPMiddleware(t *testing.T) {
+	resolver, _ := ParseTrustedProxies("127.0.0.1")
+
+	var got string
+	handler := resolver.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = ClientIP(r)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "127.0.0.1:5000"
+	req.Header.Set("X-Forwarded-For", "198.51.100.7")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got != "198.51.100.7" {
+		t.Errorf("ClientIP() = %v, want %v", got, "198.51.100.7")
+	}
+
+	if _, err := NewClientIPResolver("not-an-ip"); err == nil {
+		t.Errorf("NewClientIPResolver(not-an-ip) error = nil, want error")
+	}
+}
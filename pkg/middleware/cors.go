@@ -0,0 +1,318 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig configures CORSWith.
+type CORSConfig struct {
+	// AllowedOrigins lists origins as scheme://host[:port]. "*" allows any
+	// origin, but only without AllowCredentials, and
+	// "https://*.example.com" allows any subdomain of example.com (but not
+	// example.com itself). Empty allows none.
+	AllowedOrigins []string
+	// AllowOriginFunc is consulted for origins not matched by AllowedOrigins.
+	AllowOriginFunc func(origin string, r *http.Request) bool
+	// AllowedMethods answer preflights. Default GET, HEAD, POST, PUT, PATCH, DELETE.
+	AllowedMethods []string
+	// AllowedHeaders answer preflights; "*" allows any requested header.
+	// Default Content-Type, Authorization, X-Requested-With.
+	AllowedHeaders []string
+	// ExposedHeaders lists response headers scripts may read.
+	ExposedHeaders []string
+	// AllowCredentials lets browsers send cookies. The matched origin is
+	// echoed instead of "*", as the spec requires, so CORSWith panics if
+	// AllowedOrigins also holds an entry that would let arbitrary sites make
+	// requests with the user's cookies; see CheckCredentialedOrigin.
+	AllowCredentials bool
+	// MaxAge lets browsers cache preflight results. Zero omits the header.
+	MaxAge time.Duration
+	// Routes overrides the policy for matching paths. A trailing "*"
+	// matches a prefix and the longest matching pattern wins. Overrides
+	// are complete policies and inherit nothing from the parent.
+	Routes map[string]CORSConfig
+}
+
+type corsPolicy struct {
+	anyOrigin      bool
+	origins        map[string]bool
+	wildcards      []originWildcard
+	originFunc     func(origin string, r *http.Request) bool
+	methods        []string
+	anyHeader      bool
+	headers        map[string]bool
+	allowMethods   string
+	allowHeaders   string
+	exposedHeaders string
+	credentials    bool
+	maxAge         string
+}
+
+type corsRoute struct {
+	pattern string
+	policy  *corsPolicy
+}
+
+type originWildcard struct {
+	prefix, suffix string
+}
+
+// CORS allows credentialed requests from the usual local development origins.
+func CORS(next http.Handler) http.Handler {
+	return CORSWithOrigins(nil)(next)
+}
+
+// CORSWithOrigins allows credentialed requests from allowedOrigins, or from
+// the local development origins when none are given.
+func CORSWithOrigins(allowedOrigins []string) func(next http.Handler) http.Handler {
+	if len(allowedOrigins) == 0 {
+		allowedOrigins = []string{
+			"http://localhost:3000",
+			"http://localhost:3001",
+			"http://127.0.0.1:3000",
+			"http://127.0.0.1:3001",
+		}
+	}
+	return CORSWith(CORSConfig{
+		AllowedOrigins:   allowedOrigins,
+		AllowCredentials: true,
+		MaxAge:           24 * time.Hour,
+	})
+}
+
+// CORSWith applies config to cross-origin requests. Preflights (OPTIONS with
+// an Origin and Access-Control-Request-Method) are answered with 204 when
+// allowed and 403 otherwise; any other request, including a plain OPTIONS,
+// reaches next. Disallowed origins get no CORS headers at all. CORSWith
+// panics if config or a route override combines AllowCredentials with an
+// origin CheckCredentialedOrigin rejects.
+func CORSWith(config CORSConfig) func(next http.Handler) http.Handler {
+	root := newCORSPolicy(config)
+
+	routes := make([]corsRoute, 0, len(config.Routes))
+	for pattern, override := range config.Routes {
+		routes = append(routes, corsRoute{pattern: pattern, policy: newCORSPolicy(override)})
+	}
+	slices.SortFunc(routes, func(a, b corsRoute) int {
+		return len(b.pattern) - len(a.pattern)
+	})
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			policy := root
+			for _, route := range routes {
+				if isExemptPath(r.URL.Path, []string{route.pattern}) {
+					policy = route.policy
+					break
+				}
+			}
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && origin != "" &&
+				r.Header.Get("Access-Control-Request-Method") != ""
+
+			if preflight {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+				if !policy.allowPreflight(origin, r) {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+
+				policy.setOriginHeaders(h, origin)
+				h.Set("Access-Control-Allow-Methods", policy.allowMethods)
+				if policy.anyHeader {
+					if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+						h.Set("Access-Control-Allow-Headers", requested)
+					}
+				} else if policy.allowHeaders != "" {
+					h.Set("Access-Control-Allow-Headers", policy.allowHeaders)
+				}
+				if policy.maxAge != "" {
+					h.Set("Access-Control-Max-Age", policy.maxAge)
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			if origin != "" && policy.allowOrigin(origin, r) {
+				policy.setOriginHeaders(h, origin)
+				if policy.exposedHeaders != "" {
+					h.Set("Access-Control-Expose-Headers", policy.exposedHeaders)
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func newCORSPolicy(config CORSConfig) *corsPolicy {
+	methods := config.AllowedMethods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+	headers := config.AllowedHeaders
+	if len(headers) == 0 {
+		headers = []string{"Content-Type", "Authorization", "X-Requested-With"}
+	}
+
+	p := &corsPolicy{
+		origins:        make(map[string]bool),
+		originFunc:     config.AllowOriginFunc,
+		headers:        make(map[string]bool),
+		allowMethods:   strings.Join(methods, ", "),
+		exposedHeaders: strings.Join(config.ExposedHeaders, ", "),
+		credentials:    config.AllowCredentials,
+	}
+
+	for _, method := range methods {
+		p.methods = append(p.methods, strings.ToUpper(method))
+	}
+
+	for _, origin := range config.AllowedOrigins {
+		origin = normalizeOrigin(origin)
+		if config.AllowCredentials {
+			if err := CheckCredentialedOrigin(origin); err != nil {
+				panic("middleware: " + err.Error())
+			}
+		}
+		switch {
+		case origin == "":
+			// Tolerate blanks from splitting an empty environment variable
+		case origin == "*":
+			p.anyOrigin = true
+		case strings.Contains(origin, "*"):
+			prefix, suffix, _ := strings.Cut(origin, "*")
+			p.wildcards = append(p.wildcards, originWildcard{prefix: prefix, suffix: suffix})
+		default:
+			p.origins[origin] = true
+		}
+	}
+
+	var allowHeaders []string
+	for _, header := range headers {
+		if header == "*" {
+			p.anyHeader = true
+			continue
+		}
+		p.headers[http.CanonicalHeaderKey(header)] = true
+		allowHeaders = append(allowHeaders, header)
+	}
+	p.allowHeaders = strings.Join(allowHeaders, ", ")
+
+	if config.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(config.MaxAge.Seconds()))
+	}
+
+	return p
+}
+
+// CheckCredentialedOrigin reports whether origin, an AllowedOrigins entry,
+// is narrow enough to combine with AllowCredentials. It rejects "*" and any
+// wildcard other than a leftmost host label followed by a domain of at least
+// two labels, as in "https://*.example.com": "https://*" or
+// "https://app.*" would admit sites anyone can register. Applications can
+// call it to validate configuration before building the middleware.
+func CheckCredentialedOrigin(origin string) error {
+	origin = normalizeOrigin(origin)
+	if origin == "*" {
+		return errors.New(`CORS origin "*" cannot be combined with credentials`)
+	}
+	prefix, suffix, ok := strings.Cut(origin, "*")
+	if !ok {
+		return nil
+	}
+
+	scheme, rest, ok := strings.Cut(prefix, "://")
+	if !ok || scheme == "" || rest != "" {
+		return fmt.Errorf("CORS origin %q: with credentials \"*\" must be the leftmost host label", origin)
+	}
+	host, _, _ := strings.Cut(suffix, ":")
+	domain, ok := strings.CutPrefix(host, ".")
+	labels := strings.Split(domain, ".")
+	if !ok || strings.Contains(suffix, "*") || len(labels) < 2 || slices.Contains(labels, "") {
+		return fmt.Errorf("CORS origin %q: with credentials \"*.\" must be followed by a registrable domain", origin)
+	}
+	return nil
+}
+
+// normalizeOrigin lowercases an AllowedOrigins entry and trims the spaces and
+// trailing slash configuration tends to carry.
+func normalizeOrigin(origin string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+}
+
+func (p *corsPolicy) allowOrigin(origin string, r *http.Request) bool {
+	if p.anyOrigin {
+		return true
+	}
+	lower := strings.ToLower(origin)
+	if p.origins[lower] {
+		return true
+	}
+	for _, w := range p.wildcards {
+		if w.match(lower) {
+			return true
+		}
+	}
+	return p.originFunc != nil && p.originFunc(origin, r)
+}
+
+func (p *corsPolicy) allowPreflight(origin string, r *http.Request) bool {
+	if !p.allowOrigin(origin, r) {
+		return false
+	}
+	if !slices.Contains(p.methods, strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))) {
+		return false
+	}
+	if p.anyHeader {
+		return true
+	}
+	for _, header := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+		header = strings.TrimSpace(header)
+		if header != "" && !p.headers[http.CanonicalHeaderKey(header)] {
+			return false
+		}
+	}
+	return true
+}
+
+// setOriginHeaders is only called for allowed origins. newCORSPolicy
+// guarantees anyOrigin and credentials are never both set, so a credentialed
+// response only ever echoes a listed origin.
+func (p *corsPolicy) setOriginHeaders(h http.Header, origin string) {
+	if p.anyOrigin {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if p.credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// match requires at least one label in place of the "*" and allows only
+// host name characters there, so "https://*.example.com" neither matches
+// https://example.com nor https://evil.com/.example.com.
+func (w originWildcard) match(origin string) bool {
+	if len(origin) <= len(w.prefix)+len(w.suffix) ||
+		!strings.HasPrefix(origin, w.prefix) || !strings.HasSuffix(origin, w.suffix) {
+		return false
+	}
+	for _, c := range origin[len(w.prefix) : len(origin)-len(w.suffix)] {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
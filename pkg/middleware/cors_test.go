@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCORSWith(t *testing.T) {
+	var reached bool
+	handler := CORSWith(CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com", "https://*.example.org"},
+		AllowOriginFunc: func(origin string, r *http.Request) bool {
+			return strings.HasSuffix(origin, ".trusted.test")
+		},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+		Routes: map[string]CORSConfig{
+			"/public/*": {AllowedOrigins: []string{"*"}},
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		header      http.Header
+		wantStatus  int
+		wantOrigin  string
+		wantReached bool
+	}{
+		{"same origin", http.MethodGet, "/", nil, http.StatusOK, "", true},
+		{"exact origin", http.MethodGet, "/", http.Header{"Origin": {"https://app.example.com"}}, http.StatusOK, "https://app.example.com", true},
+		{"subdomain wildcard", http.MethodGet, "/", http.Header{"Origin": {"https://a.b.example.org"}}, http.StatusOK, "https://a.b.example.org", true},
+		{"wildcard excludes apex", http.MethodGet, "/", http.Header{"Origin": {"https://example.org"}}, http.StatusOK, "", true},
+		{"wildcard excludes lookalike", http.MethodGet, "/", http.Header{"Origin": {"https://evil.com/.example.org"}}, http.StatusOK, "", true},
+		{"predicate", http.MethodGet, "/", http.Header{"Origin": {"https://ci.trusted.test"}}, http.StatusOK, "https://ci.trusted.test", true},
+		{"disallowed origin", http.MethodGet, "/", http.Header{"Origin": {"https://evil.com"}}, http.StatusOK, "", true},
+		{"preflight", http.MethodOptions, "/", http.Header{"Origin": {"https://app.example.com"}, "Access-Control-Request-Method": {"PUT"}, "Access-Control-Request-Headers": {"content-type"}}, http.StatusNoContent, "https://app.example.com", false},
+		{"preflight bad method", http.MethodOptions, "/", http.Header{"Origin": {"https://app.example.com"}, "Access-Control-Request-Method": {"TRACE"}}, http.StatusForbidden, "", false},
+		{"preflight bad header", http.MethodOptions, "/", http.Header{"Origin": {"https://app.example.com"}, "Access-Control-Request-Method": {"GET"}, "Access-Control-Request-Headers": {"X-Secret"}}, http.StatusForbidden, "", false},
+		{"preflight bad origin", http.MethodOptions, "/", http.Header{"Origin": {"https://evil.com"}, "Access-Control-Request-Method": {"GET"}}, http.StatusForbidden, "", false},
+		{"plain options", http.MethodOptions, "/", http.Header{"Origin": {"https://app.example.com"}}, http.StatusOK, "https://app.example.com", true},
+		{"route override", http.MethodGet, "/public/data", http.Header{"Origin": {"https://anyone.net"}}, http.StatusOK, "*", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached = false
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if reached != tt.wantReached {
+				t.Errorf("handler reached = %v, want %v", reached, tt.wantReached)
+			}
+			if got := w.Header().Values("Vary"); len(got) == 0 || got[0] != "Origin" {
+				t.Errorf("Vary = %v, want Origin first", got)
+			}
+			if tt.wantOrigin == "" && w.Header().Get("Access-Control-Allow-Methods") != "" {
+				t.Errorf("Access-Control-Allow-Methods sent without an allowed origin")
+			}
+		})
+	}
+}
+
+func TestCORSWithOriginsDefaults(t *testing.T) {
+	handler := CORSWithOrigins(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Origin", "http://localhost:3000")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "http://localhost:3000" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want default development origin", got)
+	}
+}
+
+func TestCORSWithRejectsCredentialedWildcard(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func()
+	}{
+		{"root", func() {
+			CORSWith(CORSConfig{AllowedOrigins: []string{"https://app.example.com", "*"}, AllowCredentials: true})
+		}},
+		{"route override", func() {
+			CORSWith(CORSConfig{Routes: map[string]CORSConfig{
+				"/api/*": {AllowedOrigins: []string{" * "}, AllowCredentials: true},
+			}})
+		}},
+		{"CORSWithOrigins", func() { CORSWithOrigins([]string{"*"}) }},
+		{"scheme only wildcard", func() { CORSWithOrigins([]string{"https://*"}) }},
+		{"trailing wildcard", func() { CORSWithOrigins([]string{"https://app.*"}) }},
+		{"wildcard inside label", func() { CORSWithOrigins([]string{"https://app*.example.com"}) }},
+		{"wildcard over a TLD", func() { CORSWithOrigins([]string{"https://*.com"}) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("no panic for a wildcard origin with AllowCredentials")
+				}
+			}()
+			tt.build()
+		})
+	}
+}
+
+func TestCheckCredentialedOrigin(t *testing.T) {
+	tests := []struct {
+		origin  string
+		wantErr bool
+	}{
+		{"https://app.example.com", false},
+		{"https://*.example.com", false},
+		{"http://*.example.com:8080", false},
+		{"*", true},
+		{"https://*", true},
+		{"https://*:8080", true},
+		{"https://app.*", true},
+		{"https://*.com", true},
+		{"https://*example.com", true},
+		{"https://a.*.example.com", true},
+		{"*.example.com", true},
+	}
+
+	for _, tt := range tests {
+		if err := CheckCredentialedOrigin(tt.origin); (err != nil) != tt.wantErr {
+			t.Errorf("CheckCredentialedOrigin(%q) error = %v, wantErr %v", tt.origin, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type csrfContextKey struct{}
+
+const csrfTokenLength = 32
+
+// CSRFConfig configures the CSRF middleware. The zero value protects every
+// unsafe request and trusts only the request's own host.
+type CSRFConfig struct {
+	// CookieName holds the per-browser secret. Default "csrf_token".
+	CookieName string
+	// HeaderName is checked first, for HTMX (hx-headers) and fetch requests. Default "X-CSRF-Token".
+	HeaderName string
+	// FieldName is the form field checked when the header is absent. Default "csrf_token".
+	FieldName string
+	// ExemptPaths skip token checks. A trailing "*" matches a prefix.
+	ExemptPaths []string
+	// TrustedOrigins lists extra origins (scheme://host[:port]) allowed to submit
+	// unsafe requests besides the request's own host.
+	TrustedOrigins []string
+	// Secure marks the cookie Secure and rejects unsafe requests that carry
+	// neither an Origin nor a Referer header, or whose own-host origin is not
+	// https.
+	Secure bool
+	// ErrorHandler renders rejections. Default is a plain 403.
+	ErrorHandler http.Handler
+}
+
+// CSRF protects unsafe methods with the double-submit cookie pattern plus
+// Origin/Referer verification. Handlers read the token with CSRFToken or
+// emit a hidden input with CSRFField.
+func CSRF(config CSRFConfig) func(next http.Handler) http.Handler {
+	if config.CookieName == "" {
+		config.CookieName = "csrf_token"
+	}
+	if config.HeaderName == "" {
+		config.HeaderName = "X-CSRF-Token"
+	}
+	if config.FieldName == "" {
+		config.FieldName = "csrf_token"
+	}
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Forbidden - CSRF check failed", http.StatusForbidden)
+		})
+	}
+
+	trusted := make(map[string]bool, len(config.TrustedOrigins))
+	for _, origin := range config.TrustedOrigins {
+		trusted[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			secret := readCSRFSecret(r, config.CookieName)
+			if secret == nil {
+				var err error
+				secret, err = newCSRFSecret()
+				if err != nil {
+					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+					return
+				}
+				http.SetCookie(w, &http.Cookie{
+					Name:     config.CookieName,
+					Value:    base64.RawURLEncoding.EncodeToString(secret),
+					Path:     "/",
+					HttpOnly: true,
+					Secure:   config.Secure,
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+
+			r = r.WithContext(context.WithValue(r.Context(), csrfContextKey{}, csrfState{
+				secret:    secret,
+				fieldName: config.FieldName,
+			}))
+			w.Header().Add("Vary", "Cookie")
+
+			if isSafeMethod(r.Method) || isExemptPath(r.URL.Path, config.ExemptPaths) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if err := checkOrigin(r, trusted, config.Secure); err != nil {
+				slog.Warn("CSRF origin check failed", "path", r.URL.Path, "error", err)
+				config.ErrorHandler.ServeHTTP(w, r)
+				return
+			}
+
+			token := r.Header.Get(config.HeaderName)
+			if token == "" {
+				token = r.PostFormValue(config.FieldName)
+			}
+
+			if !validCSRFToken(token, secret) {
+				slog.Warn("CSRF token check failed", "path", r.URL.Path)
+				config.ErrorHandler.ServeHTTP(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type csrfState struct {
+	secret    []byte
+	fieldName string
+}
+
+// CSRFToken returns a masked token for the current request. Each call returns
+// a different value so the token cannot be recovered through compression
+// side channels; all of them validate against the same cookie.
+func CSRFToken(r *http.Request) string {
+	state, ok := r.Context().Value(csrfContextKey{}).(csrfState)
+	if !ok {
+		return ""
+	}
+	return maskCSRFToken(state.secret)
+}
+
+// CSRFField returns a hidden form input carrying the CSRF token.
+func CSRFField(r *http.Request) template.HTML {
+	state, ok := r.Context().Value(csrfContextKey{}).(csrfState)
+	if !ok {
+		return ""
+	}
+	return template.HTML(fmt.Sprintf(`<input type="hidden" name="%s" value="%s">`,
+		template.HTMLEscapeString(state.fieldName), maskCSRFToken(state.secret)))
+}
+
+func readCSRFSecret(r *http.Request, name string) []byte {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return nil
+	}
+	secret, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil || len(secret) != csrfTokenLength {
+		return nil
+	}
+	return secret
+}
+
+func newCSRFSecret() ([]byte, error) {
+	secret := make([]byte, csrfTokenLength)
+	_, err := rand.Read(secret)
+	return secret, err
+}
+
+// maskCSRFToken returns base64(pad || pad XOR secret) with a fresh random pad.
+func maskCSRFToken(secret []byte) string {
+	masked := make([]byte, 2*csrfTokenLength)
+	pad := masked[:csrfTokenLength]
+	if _, err := rand.Read(pad); err != nil {
+		return ""
+	}
+	for i := range csrfTokenLength {
+		masked[csrfTokenLength+i] = pad[i] ^ secret[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(masked)
+}
+
+func validCSRFToken(token string, secret []byte) bool {
+	masked, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(masked) != 2*csrfTokenLength {
+		return false
+	}
+
+	unmasked := make([]byte, csrfTokenLength)
+	for i := range csrfTokenLength {
+		unmasked[i] = masked[i] ^ masked[csrfTokenLength+i]
+	}
+	return subtle.ConstantTimeCompare(unmasked, secret) == 1
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func isExemptPath(path string, exempt []string) bool {
+	for _, pattern := range exempt {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// checkOrigin rejects unsafe requests whose Origin (or, failing that, Referer)
+// names a host other than the request's own or a trusted origin. When secure,
+// the request's own host only counts over https, so a page served over plain
+// http on the same host cannot submit.
+func checkOrigin(r *http.Request, trusted map[string]bool, secure bool) error {
+	source := r.Header.Get("Origin")
+	if source == "" || source == "null" {
+		source = r.Header.Get("Referer")
+		if source == "" {
+			// Browsers send Origin on cross-site POSTs; over HTTPS a
+			// request with neither header is treated as suspicious.
+			if secure {
+				return fmt.Errorf("missing Origin and Referer")
+			}
+			return nil
+		}
+	}
+
+	u, err := url.Parse(source)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("malformed origin %q", source)
+	}
+
+	if strings.EqualFold(u.Host, r.Host) && (!secure || u.Scheme == "https") {
+		return nil
+	}
+	if trusted[strings.ToLower(u.Scheme+"://"+u.Host)] {
+		return nil
+	}
+
+	return fmt.Errorf("origin %q does not match host %q", u.Scheme+"://"+u.Host, r.Host)
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCSRF(t *testing.T) {
+	var token string
+	handler := CSRF(CSRFConfig{ExemptPaths: []string{"/webhooks/*"}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = CSRFToken(r)
+	}))
+
+	// A safe request issues the cookie and a token
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || token == "" {
+		t.Fatalf("GET / set %d cookies, token %q, want 1 cookie and a token", len(cookies), token)
+	}
+
+	post := func(path string, header http.Header, form url.Values) int {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		req.AddCookie(cookies[0])
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		header http.Header
+		form   url.Values
+		want   int
+	}{
+		{"form field", "/post", nil, url.Values{"csrf_token": {token}}, http.StatusOK},
+		{"htmx header", "/post", http.Header{"X-Csrf-Token": {token}}, nil, http.StatusOK},
+		{"missing token", "/post", nil, nil, http.StatusForbidden},
+		{"wrong token", "/post", nil, url.Values{"csrf_token": {"bogus"}}, http.StatusForbidden},
+		{"cross origin", "/post", http.Header{"Origin": {"https://evil.example"}}, url.Values{"csrf_token": {token}}, http.StatusForbidden},
+		{"same origin", "/post", http.Header{"Origin": {"http://example.com"}}, url.Values{"csrf_token": {token}}, http.StatusOK},
+		{"exempt path", "/webhooks/github", nil, nil, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := post(tt.path, tt.header, tt.form); got != tt.want {
+				t.Errorf("POST %s status = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	trusted := map[string]bool{"https://app.example.org": true}
+
+	tests := []struct {
+		name    string
+		origin  string
+		referer string
+		secure  bool
+		wantErr bool
+	}{
+		{"same host", "http://example.com", "", false, false},
+		{"same host https", "https://example.com", "", true, false},
+		{"same host http when secure", "http://example.com", "", true, true},
+		{"referer http when secure", "", "http://example.com/form", true, true},
+		{"trusted origin", "https://app.example.org", "", true, false},
+		{"trusted host wrong scheme", "http://app.example.org", "", false, true},
+		{"other host", "https://evil.example", "", false, true},
+		{"no headers", "", "", false, false},
+		{"no headers when secure", "", "", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/post", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.referer != "" {
+				req.Header.Set("Referer", tt.referer)
+			}
+			if err := checkOrigin(req, trusted, tt.secure); (err != nil) != tt.wantErr {
+				t.Errorf("checkOrigin() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LimitState is the per-key state an Algorithm reads and updates. Token
+// bucket uses Tokens and Updated; sliding window uses Window, Current and
+// Previous. Stores persist every field as-is.
+type LimitState struct {
+	Tokens    float64
+	Updated   time.Time
+	Window    time.Time
+	Current   int64
+	Previous  int64
+	ExpiresAt time.Time
+}
+
+// Decision is the outcome of a single rate limit check.
+type Decision struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+	Policy     string
+}
+
+// Algorithm decides whether a request is allowed and updates state in place.
+type Algorithm interface {
+	Allow(state *LimitState, now time.Time) Decision
+}
+
+// Store persists LimitState per key. Update must run fn atomically with
+// respect to other updates of the same key.
+type Store interface {
+	Update(ctx context.Context, key string, fn func(state *LimitState)) error
+	Close() error
+}
+
+// TokenBucket allows bursts of up to Capacity requests and refills at Rate
+// tokens per second.
+type TokenBucket struct {
+	Capacity int
+	Rate     float64
+}
+
+// NewTokenBucket refills limit tokens every per, with a burst of limit.
+func NewTokenBucket(limit int, per time.Duration) *TokenBucket {
+	return &TokenBucket{
+		Capacity: limit,
+		Rate:     float64(limit) / per.Seconds(),
+	}
+}
+
+func (tb *TokenBucket) Allow(state *LimitState, now time.Time) Decision {
+	capacity := float64(tb.Capacity)
+	if state.Updated.IsZero() {
+		state.Tokens = capacity
+	} else if elapsed := now.Sub(state.Updated).Seconds(); elapsed > 0 {
+		state.Tokens = math.Min(capacity, state.Tokens+elapsed*tb.Rate)
+	}
+	state.Updated = now
+
+	d := Decision{
+		Limit:  tb.Capacity,
+		Policy: fmt.Sprintf("%d;w=%d;burst=%d", tb.Capacity, int(math.Ceil(capacity/tb.Rate)), tb.Capacity),
+	}
+
+	if state.Tokens >= 1 {
+		state.Tokens--
+		d.Allowed = true
+	} else {
+		d.RetryAfter = secondsToDuration((1 - state.Tokens) / tb.Rate)
+	}
+
+	d.Remaining = int(math.Floor(state.Tokens))
+	d.Reset = secondsToDuration((capacity - state.Tokens) / tb.Rate)
+	state.ExpiresAt = now.Add(d.Reset)
+
+	return d
+}
+
+// SlidingWindow approximates a rolling window by weighting the previous
+// fixed window's count by how much of it still overlaps the rolling window.
+// It keeps two counters per key regardless of traffic.
+type SlidingWindow struct {
+	Limit  int
+	Window time.Duration
+}
+
+func NewSlidingWindow(limit int, window time.Duration) *SlidingWindow {
+	return &SlidingWindow{Limit: limit, Window: window}
+}
+
+func (sw *SlidingWindow) Allow(state *LimitState, now time.Time) Decision {
+	windowStart := now.Truncate(sw.Window)
+	if !state.Window.Equal(windowStart) {
+		if state.Window.Equal(windowStart.Add(-sw.Window)) {
+			state.Previous = state.Current
+		} else {
+			state.Previous = 0
+		}
+		state.Current = 0
+		state.Window = windowStart
+	}
+
+	elapsed := float64(now.Sub(windowStart)) / float64(sw.Window)
+	estimated := float64(state.Previous)*(1-elapsed) + float64(state.Current)
+
+	d := Decision{
+		Limit:  sw.Limit,
+		Reset:  windowStart.Add(sw.Window).Sub(now),
+		Policy: fmt.Sprintf("%d;w=%d", sw.Limit, int(sw.Window.Seconds())),
+	}
+
+	if estimated+1 <= float64(sw.Limit) {
+		state.Current++
+		estimated++
+		d.Allowed = true
+	} else {
+		d.RetryAfter = sw.retryAfter(state, now, windowStart)
+	}
+
+	d.Remaining = max(0, sw.Limit-int(math.Ceil(estimated)))
+	state.ExpiresAt = windowStart.Add(2 * sw.Window)
+
+	return d
+}
+
+// retryAfter returns how long until the weighted estimate leaves room for one
+// more request, which may be before the current window ends.
+func (sw *SlidingWindow) retryAfter(state *LimitState, now, windowStart time.Time) time.Duration {
+	room := float64(sw.Limit) - 1 - float64(state.Current)
+	if room < 0 || state.Previous == 0 {
+		return windowStart.Add(sw.Window).Sub(now)
+	}
+
+	// Solve previous*(1-t) <= room for the window fraction t
+	t := 1 - room/float64(state.Previous)
+	wait := windowStart.Add(time.Duration(t * float64(sw.Window))).Sub(now)
+	return max(wait, time.Second)
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
+
+// Limiter applies an Algorithm to keys held in a Store.
+type Limiter struct {
+	algorithm Algorithm
+	store     Store
+	now       func() time.Time
+}
+
+func NewLimiter(algorithm Algorithm, store Store) *Limiter {
+	return &Limiter{algorithm: algorithm, store: store, now: time.Now}
+}
+
+// Allow checks and records one request for key.
+func (l *Limiter) Allow(ctx context.Context, key string) (Decision, error) {
+	var d Decision
+	err := l.store.Update(ctx, key, func(state *LimitState) {
+		d = l.algorithm.Allow(state, l.now())
+	})
+	return d, err
+}
+
+// Close releases the limiter's store and stops any background work it runs.
+func (l *Limiter) Close() error {
+	return l.store.Close()
+}
+
+// KeyFunc derives the rate limit key for a request.
+type KeyFunc func(r *http.Request) string
+
+// KeyByIP limits each client IP separately. Install ClientIPResolver
+// ahead of the limiter when running behind a proxy.
+func KeyByIP(r *http.Request) string {
+	return "ip:" + ClientIP(r)
+}
+
+// KeyByRoute limits each method and path separately, shared by all clients.
+func KeyByRoute(r *http.Request) string {
+	return "route:" + r.Method + " " + r.URL.Path
+}
+
+// KeyByUser limits each user returned by userID separately, falling back to
+// the client IP for anonymous requests.
+func KeyByUser(userID func(r *http.Request) string) KeyFunc {
+	return func(r *http.Request) string {
+		if id := userID(r); id != "" {
+			return "user:" + id
+		}
+		return KeyByIP(r)
+	}
+}
+
+// KeyBy combines several key functions, for example per IP per route.
+func KeyBy(funcs ...KeyFunc) KeyFunc {
+	return func(r *http.Request) string {
+		parts := make([]string, len(funcs))
+		for i, f := range funcs {
+			parts[i] = f(r)
+		}
+		return strings.Join(parts, "|")
+	}
+}
+
+// RateLimitConfig configures RateLimitWith.
+type RateLimitConfig struct {
+	Limiter *Limiter
+	// KeyFunc defaults to KeyByIP.
+	KeyFunc KeyFunc
+	// Prefix namespaces keys when several limiters share one store.
+	Prefix string
+	// OnLimited renders the 429 response. Headers are already set.
+	OnLimited http.Handler
+}
+
+// RateLimitWith enforces config.Limiter and sets the RateLimit-Limit,
+// RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy headers on every
+// response, plus Retry-After when the request is rejected. Store failures
+// fail open so an unavailable store does not take the site down.
+func RateLimitWith(config RateLimitConfig) func(next http.Handler) http.Handler {
+	if config.KeyFunc == nil {
+		config.KeyFunc = KeyByIP
+	}
+	if config.OnLimited == nil {
+		config.OnLimited = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Rate limit exceeded. Please try again later.", http.StatusTooManyRequests)
+		})
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			d, err := config.Limiter.Allow(r.Context(), config.Prefix+config.KeyFunc(r))
+			if err != nil {
+				slog.Error("Rate limiter unavailable", "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(d.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(d.Reset)))
+			h.Set("RateLimit-Policy", d.Policy)
+
+			if !d.Allowed {
+				h.Set("Retry-After", strconv.Itoa(ceilSeconds(d.RetryAfter)))
+				config.OnLimited.ServeHTTP(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// RateLimit limits each client IP to limit requests per sliding window using
+// an in-memory store.
+func RateLimit(limit int, window time.Duration) func(next http.Handler) http.Handler {
+	limiter := NewLimiter(NewSlidingWindow(limit, window), NewMemoryStore())
+	return RateLimitWith(RateLimitConfig{Limiter: limiter})
+}
+
+// LoginRateLimit provides stricter rate limiting for login endpoints
+func LoginRateLimit() func(next http.Handler) http.Handler {
+	return RateLimit(5, 5*time.Minute) // 5 attempts per 5 minutes
+}
+
+// APIRateLimit provides general rate limiting for API endpoints
+func APIRateLimit() func(next http.Handler) http.Handler {
+	return RateLimit(100, time.Minute) // 100 requests per minute
+}
+
+// GeneralRateLimit provides basic rate limiting for general endpoints
+func GeneralRateLimit(next http.Handler) http.Handler {
+	return RateLimit(60, time.Minute)(next) // 60 requests per minute
+}
@@ -0,0 +1,216 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+const (
+	memoryStoreShards = 32
+	sweepInterval     = time.Minute
+)
+
+// MemoryStore is a sharded in-process Store. Expired keys are swept from a
+// shard while it is already locked for an update, so it runs no goroutines.
+type MemoryStore struct {
+	shards [memoryStoreShards]memoryShard
+	now    func() time.Time
+}
+
+type memoryShard struct {
+	mu        sync.Mutex
+	entries   map[string]*LimitState
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{now: time.Now}
+	for i := range s.shards {
+		s.shards[i].entries = make(map[string]*LimitState)
+	}
+	return s
+}
+
+func (s *MemoryStore) shard(key string) *memoryShard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return &s.shards[h.Sum32()%memoryStoreShards]
+}
+
+func (s *MemoryStore) Update(_ context.Context, key string, fn func(state *LimitState)) error {
+	shard := s.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(shard.lastSweep) >= sweepInterval {
+		for k, state := range shard.entries {
+			if now.After(state.ExpiresAt) {
+				delete(shard.entries, k)
+			}
+		}
+		shard.lastSweep = now
+	}
+
+	state, ok := shard.entries[key]
+	if !ok || now.After(state.ExpiresAt) {
+		state = &LimitState{}
+		shard.entries[key] = state
+	}
+	fn(state)
+
+	return nil
+}
+
+// Len returns the number of tracked keys.
+func (s *MemoryStore) Len() int {
+	n := 0
+	for i := range s.shards {
+		s.shards[i].mu.Lock()
+		n += len(s.shards[i].entries)
+		s.shards[i].mu.Unlock()
+	}
+	return n
+}
+
+// Close drops all tracked keys.
+func (s *MemoryStore) Close() error {
+	for i := range s.shards {
+		s.shards[i].mu.Lock()
+		s.shards[i].entries = make(map[string]*LimitState)
+		s.shards[i].mu.Unlock()
+	}
+	return nil
+}
+
+// SQLiteStore keeps limiter state in a SQLite table so several processes
+// sharing one database file enforce a single limit. The table holds
+// short-lived counters rather than application data, so the store creates it
+// itself instead of going through an app's migrations.
+type SQLiteStore struct {
+	db        *sql.DB
+	mu        sync.Mutex
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewSQLiteStore creates the rate_limits table in db if needed.
+func NewSQLiteStore(db *sql.DB) (*SQLiteStore, error) {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS rate_limits (
+			key TEXT PRIMARY KEY,
+			tokens REAL NOT NULL DEFAULT 0,
+			updated_at INTEGER NOT NULL DEFAULT 0,
+			window_start INTEGER NOT NULL DEFAULT 0,
+			current_count INTEGER NOT NULL DEFAULT 0,
+			previous_count INTEGER NOT NULL DEFAULT 0,
+			expires_at INTEGER NOT NULL
+		)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rate_limits table: %w", err)
+	}
+
+	return &SQLiteStore{db: db, now: time.Now}, nil
+}
+
+// Update runs fn inside a BEGIN IMMEDIATE transaction, which takes SQLite's
+// write lock up front so concurrent processes serialize on the key.
+func (s *SQLiteStore) Update(ctx context.Context, key string, fn func(state *LimitState)) (err error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			conn.ExecContext(context.Background(), "ROLLBACK")
+		}
+	}()
+
+	now := s.now()
+	var state LimitState
+	var updated, windowStart, expiresAt int64
+	err = conn.QueryRowContext(ctx, `
+		SELECT tokens, updated_at, window_start, current_count, previous_count, expires_at
+		FROM rate_limits WHERE key = ?`, key,
+	).Scan(&state.Tokens, &updated, &windowStart, &state.Current, &state.Previous, &expiresAt)
+
+	switch {
+	case err == sql.ErrNoRows || (err == nil && now.UnixNano() > expiresAt):
+		state = LimitState{}
+	case err != nil:
+		return fmt.Errorf("failed to read rate limit: %w", err)
+	default:
+		state.Updated = unixNanoTime(updated)
+		state.Window = unixNanoTime(windowStart)
+	}
+
+	fn(&state)
+
+	_, err = conn.ExecContext(ctx, `
+		INSERT INTO rate_limits (key, tokens, updated_at, window_start, current_count, previous_count, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = excluded.tokens,
+			updated_at = excluded.updated_at,
+			window_start = excluded.window_start,
+			current_count = excluded.current_count,
+			previous_count = excluded.previous_count,
+			expires_at = excluded.expires_at`,
+		key, state.Tokens, timeUnixNano(state.Updated), timeUnixNano(state.Window),
+		state.Current, state.Previous, state.ExpiresAt.UnixNano(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write rate limit: %w", err)
+	}
+
+	if s.shouldSweep(now) {
+		if _, err = conn.ExecContext(ctx, `DELETE FROM rate_limits WHERE expires_at < ?`, now.UnixNano()); err != nil {
+			return fmt.Errorf("failed to sweep rate limits: %w", err)
+		}
+	}
+
+	if _, err = conn.ExecContext(ctx, "COMMIT"); err != nil {
+		return fmt.Errorf("failed to commit rate limit: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteStore) shouldSweep(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return false
+	}
+	s.lastSweep = now
+	return true
+}
+
+// Close is a no-op; the caller owns the database handle.
+func (s *SQLiteStore) Close() error {
+	return nil
+}
+
+func unixNanoTime(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+func timeUnixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	tb := NewTokenBucket(3, 3*time.Second)
+	var state LimitState
+	now := time.Unix(1000, 0)
+
+	for i := range 3 {
+		if d := tb.Allow(&state, now); !d.Allowed {
+			t.Fatalf("request %d Allowed = false, want true", i+1)
+		}
+	}
+
+	d := tb.Allow(&state, now)
+	if d.Allowed || d.Remaining != 0 {
+		t.Errorf("burst exhausted: Allowed = %v, Remaining = %v, want false, 0", d.Allowed, d.Remaining)
+	}
+	if d.RetryAfter != time.Second {
+		t.Errorf("RetryAfter = %v, want %v", d.RetryAfter, time.Second)
+	}
+
+	// One token refills per second
+	if d := tb.Allow(&state, now.Add(time.Second)); !d.Allowed {
+		t.Errorf("after refill Allowed = false, want true")
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	sw := NewSlidingWindow(4, time.Minute)
+	var state LimitState
+	start := time.Unix(6000, 0) // aligned to a minute
+
+	for range 4 {
+		sw.Allow(&state, start)
+	}
+	if d := sw.Allow(&state, start.Add(30*time.Second)); d.Allowed {
+		t.Errorf("over limit in same window Allowed = true, want false")
+	}
+
+	// Halfway through the next window half of the previous count still counts
+	if d := sw.Allow(&state, start.Add(90*time.Second)); !d.Allowed || d.Remaining != 1 {
+		t.Errorf("next window Allowed = %v, Remaining = %v, want true, 1", d.Allowed, d.Remaining)
+	}
+	sw.Allow(&state, start.Add(90*time.Second))
+	d := sw.Allow(&state, start.Add(90*time.Second))
+	if d.Allowed {
+		t.Errorf("weighted limit Allowed = true, want false")
+	}
+	if d.RetryAfter != 15*time.Second {
+		t.Errorf("RetryAfter = %v, want %v", d.RetryAfter, 15*time.Second)
+	}
+
+	// Two windows later the previous count no longer applies
+	if d := sw.Allow(&state, start.Add(3*time.Minute)); d.Remaining != 3 {
+		t.Errorf("idle window Remaining = %v, want 3", d.Remaining)
+	}
+}
+
+func TestMemoryStoreSweepsExpired(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Unix(1000, 0)
+	store.now = func() time.Time { return now }
+
+	for _, key := range []string{"a", "b", "c"} {
+		store.Update(context.Background(), key, func(state *LimitState) {
+			state.ExpiresAt = now.Add(time.Second)
+		})
+	}
+	if got := store.Len(); got != 3 {
+		t.Fatalf("Len() = %v, want 3", got)
+	}
+
+	// Every shard sweeps on its next update after the interval
+	now = now.Add(2 * sweepInterval)
+	for i := range 1000 {
+		store.Update(context.Background(), string(rune('d'+i)), func(state *LimitState) {
+			state.ExpiresAt = now.Add(time.Hour)
+		})
+	}
+	if got := store.Len(); got != 1000 {
+		t.Errorf("Len() after sweep = %v, want 1000", got)
+	}
+
+	store.Close()
+	if got := store.Len(); got != 0 {
+		t.Errorf("Len() after Close() = %v, want 0", got)
+	}
+}
+
+func TestRateLimitWith(t *testing.T) {
+	limiter := NewLimiter(NewSlidingWindow(2, time.Minute), NewMemoryStore())
+	defer limiter.Close()
+
+	handler := RateLimitWith(RateLimitConfig{Limiter: limiter, KeyFunc: KeyBy(KeyByIP, KeyByRoute)})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	request := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	request("/login")
+	w := request("/login")
+	if w.Code != http.StatusOK || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("second request = %v, Remaining %q, want 200, \"0\"", w.Code, w.Header().Get("RateLimit-Remaining"))
+	}
+	if got := w.Header().Get("RateLimit-Policy"); got != "2;w=60" {
+		t.Errorf("RateLimit-Policy = %q, want %q", got, "2;w=60")
+	}
+
+	w = request("/login")
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("third request = %v, want %v", w.Code, http.StatusTooManyRequests)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Errorf("Retry-After header missing on 429")
+	}
+
+	// Routes are limited separately
+	if w := request("/register"); w.Code != http.StatusOK {
+		t.Errorf("other route = %v, want %v", w.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"runtime/debug"
+	"strings"
+)
+
+// RecoverConfig configures RecoverWith.
+type RecoverConfig struct {
+	// Reporter is called after the panic is logged, for example to forward
+	// it to an error tracker. It must not write to the response.
+	Reporter func(r *http.Request, err error, stack []byte)
+}
+
+// Recover turns panics into logged 500 responses.
+func Recover(next http.Handler) http.Handler {
+	return RecoverWith(RecoverConfig{})(next)
+}
+
+// RecoverWith catches panics in next, logs them with their stack trace and
+// request ID, and answers with a 500 suited to the client: an HTML fragment
+// for HTMX requests, JSON for API clients, or a full HTML page. Install it
+// inside AccessLog so the request ID and the final status are available.
+func RecoverWith(config RecoverConfig) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sw := WrapStatusWriter(w)
+
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					// Deliberate abort; let net/http close the connection quietly
+					panic(rec)
+				}
+
+				err, ok := rec.(error)
+				if !ok {
+					err = fmt.Errorf("%v", rec)
+				}
+				stack := debug.Stack()
+
+				RequestLogger(r).Error("Panic recovered",
+					"error", err,
+					"method", r.Method,
+					"path", r.URL.Path,
+					"stack", string(stack),
+				)
+
+				if config.Reporter != nil {
+					config.Reporter(r, err, stack)
+				}
+
+				// Too late to change the status once the response has started
+				if !sw.WroteHeader() {
+					writePanicResponse(sw, r)
+				}
+			}()
+
+			next.ServeHTTP(sw, r)
+		})
+	}
+}
+
+var panicPage = template.Must(template.New("panic").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <title>Internal Server Error</title>
+</head>
+<body>
+    <h1>Something went wrong</h1>
+    <p>The error has been logged. Please try again later.</p>
+    {{if .}}<p><small>Reference: {{.}}</small></p>{{end}}
+</body>
+</html>
+`))
+
+var panicFragment = template.Must(template.New("fragment").Parse(
+	`<div class="error" role="alert">Something went wrong. Please try again.{{if .}} <small>(ref: {{.}})</small>{{end}}</div>`))
+
+func writePanicResponse(w http.ResponseWriter, r *http.Request) {
+	id := RequestID(r)
+	h := w.Header()
+	// Drop headers the handler set for the response it never finished
+	h.Del("Content-Length")
+	h.Del("Content-Disposition")
+	h.Set("Cache-Control", "no-store")
+
+	switch {
+	case r.Header.Get("HX-Request") == "true":
+		h.Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusInternalServerError)
+		panicFragment.Execute(w, id)
+	case prefersJSON(r):
+		h.Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		body := map[string]string{"error": "Internal Server Error"}
+		if id != "" {
+			body["request_id"] = id
+		}
+		json.NewEncoder(w).Encode(body)
+	default:
+		h.Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusInternalServerError)
+		panicPage.Execute(w, id)
+	}
+}
+
+// prefersJSON reports whether the Accept header lists JSON ahead of HTML.
+// Quality values are rare from API clients and browsers alike, so list order
+// is a good enough signal.
+func prefersJSON(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		switch mediaType {
+		case "application/json", "application/problem+json":
+			return true
+		case "text/html", "application/xhtml+xml":
+			return false
+		}
+	}
+	return strings.HasPrefix(r.URL.Path, "/api/")
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	var reported error
+	panicking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("template exploded")
+	})
+	handler := AccessLog(AccessLogConfig{})(RecoverWith(RecoverConfig{
+		Reporter: func(r *http.Request, err error, stack []byte) { reported = err },
+	})(panicking))
+
+	tests := []struct {
+		name        string
+		path        string
+		header      http.Header
+		contentType string
+		body        string
+	}{
+		{"htmx fragment", "/post", http.Header{"Hx-Request": {"true"}}, "text/html; charset=utf-8", `<div class="error"`},
+		{"json client", "/like/1", http.Header{"Accept": {"application/json"}}, "application/json", `"request_id":"req-1"`},
+		{"api path", "/api/posts", nil, "application/json", `"error":"Internal Server Error"`},
+		{"browser page", "/", http.Header{"Accept": {"text/html,application/json;q=0.9"}}, "text/html; charset=utf-8", "<!DOCTYPE html>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reported = nil
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			req.Header.Set("X-Request-ID", "req-1")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusInternalServerError {
+				t.Errorf("status = %v, want %v", w.Code, http.StatusInternalServerError)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if !strings.Contains(w.Body.String(), tt.body) {
+				t.Errorf("body = %q, want it to contain %q", w.Body.String(), tt.body)
+			}
+			if reported == nil || reported.Error() != "template exploded" {
+				t.Errorf("Reporter got %v, want %q", reported, "template exploded")
+			}
+		})
+	}
+}
+
+func TestRecoverAfterWrite(t *testing.T) {
+	handler := Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("late")
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusOK || w.Body.String() != "partial" {
+		t.Errorf("response = %v %q, want the partial response left untouched", w.Code, w.Body.String())
+	}
+}
+
+func TestRecoverRepanicsAbort(t *testing.T) {
+	handler := Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if rec := recover(); rec != http.ErrAbortHandler {
+			t.Errorf("recover() = %v, want http.ErrAbortHandler", rec)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
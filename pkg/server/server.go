@@ -0,0 +1,223 @@
+// Package server runs an http.Server with graceful shutdown: it stops on
+// SIGINT or SIGTERM, drains in-flight requests, then runs shutdown hooks in
+// the order they were registered.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Config configures a Server. Only Addr and Handler need setting; the
+// timeouts and shutdown behaviour have production defaults. Applications
+// embed it in the struct they load with pkg/config.
+type Config struct {
+	// Addr is the TCP address to listen on, such as ":8080".
+	Addr    string `config:"addr" usage:"TCP address to listen on"`
+	Handler http.Handler
+	// ReadTimeout default 5s, WriteTimeout default 10s, IdleTimeout
+	// default 120s.
+	ReadTimeout  time.Duration `config:"read_timeout" usage:"maximum duration for reading a request"`
+	WriteTimeout time.Duration `config:"write_timeout" usage:"maximum duration for writing a response"`
+	IdleTimeout  time.Duration `config:"idle_timeout" usage:"how long keep-alive connections stay open"`
+	// DrainDelay keeps serving after readiness flips so load balancers
+	// can stop routing new requests first. Default 0.
+	DrainDelay time.Duration `config:"drain_delay" usage:"how long to keep serving after readiness fails on shutdown"`
+	// DrainTimeout bounds how long in-flight requests may finish before
+	// connections are closed. Default 15s.
+	DrainTimeout time.Duration `config:"drain_timeout" usage:"how long in-flight requests may take to finish on shutdown"`
+	// HookTimeout bounds each shutdown hook. Default 5s.
+	HookTimeout time.Duration `config:"hook_timeout" usage:"time limit for each shutdown hook"`
+	// Signals trigger shutdown. Default SIGINT and SIGTERM.
+	Signals []os.Signal
+	// Logger default slog.Default().
+	Logger *slog.Logger
+}
+
+// WithDefaults returns c with zero timeouts replaced by their defaults, so
+// printed configuration shows the values in effect.
+func (c Config) WithDefaults() Config {
+	if c.ReadTimeout == 0 {
+		c.ReadTimeout = 5 * time.Second
+	}
+	if c.WriteTimeout == 0 {
+		c.WriteTimeout = 10 * time.Second
+	}
+	if c.IdleTimeout == 0 {
+		c.IdleTimeout = 120 * time.Second
+	}
+	if c.DrainTimeout == 0 {
+		c.DrainTimeout = 15 * time.Second
+	}
+	if c.HookTimeout == 0 {
+		c.HookTimeout = 5 * time.Second
+	}
+	return c
+}
+
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Server owns an http.Server and its shutdown sequence.
+type Server struct {
+	config Config
+	http   *http.Server
+	ready  atomic.Bool
+
+	mu    sync.Mutex
+	hooks []hook
+}
+
+func New(config Config) *Server {
+	config = config.WithDefaults()
+	if len(config.Signals) == 0 {
+		config.Signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
+	return &Server{
+		config: config,
+		http: &http.Server{
+			Addr:         config.Addr,
+			Handler:      config.Handler,
+			ReadTimeout:  config.ReadTimeout,
+			WriteTimeout: config.WriteTimeout,
+			IdleTimeout:  config.IdleTimeout,
+		},
+	}
+}
+
+// OnShutdown registers fn to run after requests have drained. Hooks run
+// one at a time in registration order, so register dependents (workers,
+// limiters) before what they depend on (the database).
+func (s *Server) OnShutdown(name string, fn func(ctx context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, hook{name: name, fn: fn})
+}
+
+// Ready reports whether the server is accepting traffic. It turns true once
+// listening and false as soon as shutdown begins.
+func (s *Server) Ready() bool {
+	return s.ready.Load()
+}
+
+// Run listens on Config.Addr and serves until ctx is cancelled, a shutdown
+// signal arrives or the listener fails, then shuts down gracefully.
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.config.Addr)
+	if err != nil {
+		s.runHooks()
+		return fmt.Errorf("failed to listen on %s: %w", s.config.Addr, err)
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve is Run on an existing listener.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, s.config.Signals...)
+	defer signal.Stop(signals)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.http.Serve(ln)
+	}()
+	s.ready.Store(true)
+	s.config.Logger.Info("Server started", "addr", ln.Addr().String())
+
+	var errs []error
+	select {
+	case err := <-serveErr:
+		// The listener failed before any shutdown was requested
+		s.ready.Store(false)
+		errs = append(errs, fmt.Errorf("server failed: %w", err))
+		return errors.Join(append(errs, s.runHooks())...)
+	case sig := <-signals:
+		s.config.Logger.Info("Shutdown signal received", "signal", sig.String())
+	case <-ctx.Done():
+		s.config.Logger.Info("Shutdown requested", "reason", context.Cause(ctx))
+	}
+
+	if err := s.drain(signals); err != nil {
+		errs = append(errs, err)
+	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		errs = append(errs, fmt.Errorf("server failed: %w", err))
+	}
+	if err := s.runHooks(); err != nil {
+		errs = append(errs, err)
+	}
+
+	s.config.Logger.Info("Server stopped")
+	return errors.Join(errs...)
+}
+
+// drain flips readiness, waits DrainDelay, then lets in-flight requests
+// finish. A second signal or the drain timeout closes connections at once.
+func (s *Server) drain(signals <-chan os.Signal) error {
+	s.ready.Store(false)
+
+	if s.config.DrainDelay > 0 {
+		select {
+		case <-time.After(s.config.DrainDelay):
+		case <-signals:
+			s.config.Logger.Warn("Second signal received, closing connections")
+			return s.http.Close()
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.DrainTimeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-signals:
+			s.config.Logger.Warn("Second signal received, closing connections")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	start := time.Now()
+	if err := s.http.Shutdown(ctx); err != nil {
+		s.http.Close()
+		return fmt.Errorf("failed to drain connections: %w", err)
+	}
+	s.config.Logger.Info("Connections drained", "duration", time.Since(start))
+	return nil
+}
+
+func (s *Server) runHooks() error {
+	s.mu.Lock()
+	hooks := s.hooks
+	s.hooks = nil
+	s.mu.Unlock()
+
+	var errs []error
+	for _, h := range hooks {
+		ctx, cancel := context.WithTimeout(context.Background(), s.config.HookTimeout)
+		err := h.fn(ctx)
+		cancel()
+		if err != nil {
+			s.config.Logger.Error("Shutdown hook failed", "hook", h.name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+			continue
+		}
+		s.config.Logger.Info("Shutdown hook completed", "hook", h.name)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestServeDrainsAndRunsHooks(t *testing.T) {
+	started := make(chan struct{})
+	var srv *Server
+	var readyDuringDrain bool
+	srv = New(Config{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			time.Sleep(100 * time.Millisecond)
+			readyDuringDrain = srv.Ready()
+			io.WriteString(w, "done")
+		}),
+		DrainTimeout: time.Second,
+	})
+
+	var order []string
+	srv.OnShutdown("workers", func(ctx context.Context) error {
+		order = append(order, "workers")
+		return nil
+	})
+	srv.OnShutdown("database", func(ctx context.Context) error {
+		order = append(order, "database")
+		return errors.New("close failed")
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- srv.Serve(ctx, ln) }()
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+
+	<-started
+	if !srv.Ready() {
+		t.Errorf("Ready() while serving = false, want true")
+	}
+	cancel()
+
+	if got := <-body; got != "done" {
+		t.Errorf("in-flight response = %q, want %q", got, "done")
+	}
+	err = <-result
+	if err == nil || err.Error() != "database: close failed" {
+		t.Errorf("Serve() error = %v, want the failing hook's error", err)
+	}
+	if readyDuringDrain {
+		t.Errorf("Ready() during drain = true, want false")
+	}
+	if want := []string{"workers", "database"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("hook order = %v, want %v", order, want)
+	}
+}
+
+func TestRunListenFailureRunsHooks(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer ln.Close()
+
+	srv := New(Config{Addr: ln.Addr().String(), Handler: http.NotFoundHandler()})
+	closed := false
+	srv.OnShutdown("database", func(ctx context.Context) error {
+		closed = true
+		return nil
+	})
+
+	if err := srv.Run(context.Background()); err == nil {
+		t.Errorf("Run() on a used address error = nil, want error")
+	}
+	if !closed {
+		t.Errorf("shutdown hook did not run after listen failure")
+	}
+}
@@ -0,0 +1,247 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// JSONExporter writes each span as one JSON line, for development and for
+// log pipelines that collect standard output.
+type JSONExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONExporter writes to w, or to standard output when w is nil.
+func NewJSONExporter(w io.Writer) *JSONExporter {
+	if w == nil {
+		w = os.Stdout
+	}
+	return &JSONExporter{w: w}
+}
+
+type jsonSpan struct {
+	Name         string         `json:"name"`
+	TraceID      string         `json:"trace_id"`
+	SpanID       string         `json:"span_id"`
+	ParentSpanID string         `json:"parent_span_id,omitempty"`
+	Kind         string         `json:"kind"`
+	Start        time.Time      `json:"start"`
+	End          time.Time      `json:"end"`
+	DurationMS   float64        `json:"duration_ms"`
+	Attributes   map[string]any `json:"attributes,omitempty"`
+	Status       string         `json:"status,omitempty"`
+	Message      string         `json:"status_message,omitempty"`
+}
+
+func (e *JSONExporter) ExportSpans(ctx context.Context, spans []SpanData) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, s := range spans {
+		out := jsonSpan{
+			Name:       s.Name,
+			TraceID:    s.SpanContext.TraceID.String(),
+			SpanID:     s.SpanContext.SpanID.String(),
+			Kind:       s.Kind.String(),
+			Start:      s.Start,
+			End:        s.End,
+			DurationMS: float64(s.End.Sub(s.Start).Microseconds()) / 1000,
+			Message:    s.StatusMessage,
+		}
+		if s.Parent.IsValid() {
+			out.ParentSpanID = s.Parent.String()
+		}
+		if len(s.Attributes) > 0 {
+			out.Attributes = make(map[string]any, len(s.Attributes))
+			for _, a := range s.Attributes {
+				out.Attributes[a.Key] = a.Value
+			}
+		}
+		switch s.Status {
+		case StatusOK:
+			out.Status = "ok"
+		case StatusError:
+			out.Status = "error"
+		}
+		if err := enc.Encode(out); err != nil {
+			return fmt.Errorf("failed to encode span: %w", err)
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err := e.w.Write(buf.Bytes())
+	return err
+}
+
+func (e *JSONExporter) Shutdown(ctx context.Context) error { return nil }
+
+func (k SpanKind) String() string {
+	switch k {
+	case SpanKindServer:
+		return "server"
+	case SpanKindClient:
+		return "client"
+	}
+	return "internal"
+}
+
+// OTLPConfig configures NewOTLPExporter. The zero value sends to a collector
+// on localhost at the standard OTLP/HTTP port.
+type OTLPConfig struct {
+	// Endpoint default http://localhost:4318/v1/traces.
+	Endpoint string
+	// ServiceName is sent as the service.name resource attribute.
+	ServiceName string
+	// Headers are added to every request, e.g. for collector auth.
+	Headers map[string]string
+	// Timeout per export. Default 10s.
+	Timeout time.Duration
+	// Client default is a new http.Client.
+	Client *http.Client
+}
+
+// OTLPExporter sends spans to an OpenTelemetry collector using OTLP/HTTP
+// with JSON encoding.
+type OTLPExporter struct {
+	config OTLPConfig
+}
+
+func NewOTLPExporter(config OTLPConfig) *OTLPExporter {
+	if config.Endpoint == "" {
+		config.Endpoint = "http://localhost:4318/v1/traces"
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{}
+	}
+	return &OTLPExporter{config: config}
+}
+
+// The OTLP/JSON shapes, trimmed to the fields this package produces.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		TraceState        string         `json:"traceState,omitempty"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Name              string         `json:"name"`
+		Kind              SpanKind       `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Status            otlpStatus     `json:"status"`
+	}
+	otlpStatus struct {
+		Code    StatusCode `json:"code,omitempty"`
+		Message string     `json:"message,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string         `json:"key"`
+		Value map[string]any `json:"value"`
+	}
+)
+
+func (e *OTLPExporter) ExportSpans(ctx context.Context, spans []SpanData) error {
+	scope := otlpScopeSpans{Scope: otlpScope{Name: "github.com/dunamismax/go-stdlib/pkg/tracing"}}
+	for _, s := range spans {
+		span := otlpSpan{
+			TraceID:           s.SpanContext.TraceID.String(),
+			SpanID:            s.SpanContext.SpanID.String(),
+			TraceState:        s.SpanContext.TraceState,
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Status:            otlpStatus{Code: s.Status, Message: s.StatusMessage},
+		}
+		if s.Parent.IsValid() {
+			span.ParentSpanID = s.Parent.String()
+		}
+		for _, a := range s.Attributes {
+			span.Attributes = append(span.Attributes, otlpAttribute(a))
+		}
+		scope.Spans = append(scope.Spans, span)
+	}
+
+	body, err := json.Marshal(otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpKeyValue{otlpAttribute(String("service.name", e.config.ServiceName))}},
+		ScopeSpans: []otlpScopeSpans{scope},
+	}}})
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.config.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create export request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.config.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export spans: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("failed to export spans: collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	e.config.Client.CloseIdleConnections()
+	return nil
+}
+
+// otlpAttribute encodes a as an OTLP AnyValue; 64-bit integers are strings
+// in OTLP/JSON.
+func otlpAttribute(a Attribute) otlpKeyValue {
+	var value map[string]any
+	switch v := a.Value.(type) {
+	case string:
+		value = map[string]any{"stringValue": v}
+	case int64:
+		value = map[string]any{"intValue": strconv.FormatInt(v, 10)}
+	case float64:
+		value = map[string]any{"doubleValue": v}
+	case bool:
+		value = map[string]any{"boolValue": v}
+	default:
+		value = map[string]any{"stringValue": fmt.Sprint(v)}
+	}
+	return otlpKeyValue{Key: a.Key, Value: value}
+}
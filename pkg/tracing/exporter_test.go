@@ -0,0 +1,125 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSpans() []SpanData {
+	start := time.Unix(1700000000, 500)
+	return []SpanData{{
+		Name:        "SELECT",
+		SpanContext: SpanContext{TraceID: TraceID{1}, SpanID: SpanID{2}},
+		Parent:      SpanID{3},
+		Kind:        SpanKindClient,
+		Start:       start,
+		End:         start.Add(1500 * time.Microsecond),
+		Attributes:  []Attribute{String("db.system.name", "sqlite"), Int("rows", 3), Bool("cached", false)},
+		Status:      StatusError,
+	}}
+}
+
+func TestJSONExporter(t *testing.T) {
+	var out bytes.Buffer
+	if err := NewJSONExporter(&out).ExportSpans(context.Background(), testSpans()); err != nil {
+		t.Fatalf("ExportSpans() error = %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("output %q is not JSON: %v", out.String(), err)
+	}
+	for key, want := range map[string]any{
+		"name":           "SELECT",
+		"trace_id":       "01000000000000000000000000000000",
+		"parent_span_id": "0300000000000000",
+		"kind":           "client",
+		"duration_ms":    1.5,
+		"status":         "error",
+	} {
+		if got[key] != want {
+			t.Errorf("%s = %v, want %v", key, got[key], want)
+		}
+	}
+}
+
+// TestOTLPExporter posts to a collector stub, checking the OTLP/JSON
+// encoding and that a rejected export is an error.
+func TestOTLPExporter(t *testing.T) {
+	var received otlpRequest
+	status := http.StatusOK
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("collector got %s %s (%s)", r.Method, r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("collector got Authorization %q", r.Header.Get("Authorization"))
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(status)
+	}))
+	defer collector.Close()
+
+	exporter := NewOTLPExporter(OTLPConfig{
+		Endpoint:    collector.URL + "/v1/traces",
+		ServiceName: "go-social",
+		Headers:     map[string]string{"Authorization": "Bearer token"},
+	})
+	if err := exporter.ExportSpans(context.Background(), testSpans()); err != nil {
+		t.Fatalf("ExportSpans() error = %v", err)
+	}
+
+	rs := received.ResourceSpans
+	if len(rs) != 1 || rs[0].Resource.Attributes[0].Value["stringValue"] != "go-social" {
+		t.Fatalf("resource = %+v, want service.name go-social", rs)
+	}
+	spans := rs[0].ScopeSpans[0].Spans
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	s := spans[0]
+	if s.TraceID != "01000000000000000000000000000000" || s.ParentSpanID != "0300000000000000" {
+		t.Errorf("IDs = %s/%s", s.TraceID, s.ParentSpanID)
+	}
+	if s.StartTimeUnixNano != "1700000000000000500" || s.Kind != SpanKindClient || s.Status.Code != StatusError {
+		t.Errorf("span = %+v", s)
+	}
+	if s.Attributes[1].Value["intValue"] != "3" || s.Attributes[2].Value["boolValue"] != false {
+		t.Errorf("attributes = %+v", s.Attributes)
+	}
+
+	status = http.StatusServiceUnavailable
+	err := exporter.ExportSpans(context.Background(), testSpans())
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("ExportSpans() to failing collector error = %v, want 503", err)
+	}
+	if err := exporter.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+}
+
+func TestTracerExportFailureIsLogged(t *testing.T) {
+	failing := exporterFunc(func(context.Context, []SpanData) error { return errors.New("down") })
+	tracer, err := New(Config{Exporter: failing, Logger: slog.New(slog.DiscardHandler)})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_, span := tracer.start(context.Background(), "op", SpanKindInternal, SpanContext{}, nil)
+	span.End()
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown() error = %v, want export failures logged not returned", err)
+	}
+}
+
+type exporterFunc func(context.Context, []SpanData) error
+
+func (f exporterFunc) ExportSpans(ctx context.Context, spans []SpanData) error { return f(ctx, spans) }
+
+func (f exporterFunc) Shutdown(context.Context) error { return nil }
@@ -0,0 +1,7 @@
+module github.com/dunamismax/go-stdlib/pkg/tracing
+
+go 1.24
+
+require github.com/dunamismax/go-stdlib/pkg/middleware v0.0.0
+
+replace github.com/dunamismax/go-stdlib/pkg/middleware => ../middleware
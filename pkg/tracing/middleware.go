@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dunamismax/go-stdlib/pkg/middleware"
+)
+
+// Middleware starts a server span for each request, continuing the caller's
+// trace when a valid traceparent header arrives. The span is named
+// "METHOD route" using route, which may be nil, once the handler returns.
+// Responses with a 5xx status mark the span failed.
+func (t *Tracer) Middleware(route func(r *http.Request) string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if t == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			parent, _ := Extract(r.Header)
+			ctx, span := t.start(r.Context(), r.Method, SpanKindServer, parent, []Attribute{
+				String("http.request.method", r.Method),
+				String("url.path", r.URL.Path),
+				String("client.address", middleware.ClientIP(r)),
+				String("user_agent.original", r.UserAgent()),
+			})
+			defer span.End()
+
+			r = r.WithContext(ctx)
+			sw := middleware.WrapStatusWriter(w)
+			next.ServeHTTP(sw, r)
+
+			status := sw.Status()
+			span.SetAttributes(Int("http.response.status_code", status))
+			if route != nil {
+				if name := route(r); name != "" {
+					// Drop the method prefix of ServeMux patterns
+					if _, path, ok := strings.Cut(name, " "); ok {
+						name = path
+					}
+					span.SetName(r.Method + " " + name)
+					span.SetAttributes(String("http.route", name))
+				}
+			}
+			if status >= 500 {
+				span.SetError(httpError(status))
+			}
+		})
+	}
+}
+
+type httpError int
+
+func (e httpError) Error() string {
+	return http.StatusText(int(e))
+}
@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// Extract parses the W3C traceparent and tracestate headers. It reports
+// false when traceparent is missing or malformed, in which case the caller
+// starts a new trace.
+func Extract(h http.Header) (SpanContext, bool) {
+	value := strings.TrimSpace(h.Get("traceparent"))
+	// version-traceid-parentid-flags; later versions may append fields
+	if len(value) < 55 || value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return SpanContext{}, false
+	}
+
+	var version [1]byte
+	if _, err := hex.Decode(version[:], []byte(value[0:2])); err != nil || version[0] == 0xff {
+		return SpanContext{}, false
+	}
+	if version[0] == 0 && len(value) != 55 || version[0] > 0 && len(value) > 55 && value[55] != '-' {
+		return SpanContext{}, false
+	}
+	if strings.ToLower(value[:55]) != value[:55] {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+	var flags [1]byte
+	if _, err := hex.Decode(sc.TraceID[:], []byte(value[3:35])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(value[36:52])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(flags[:], []byte(value[53:55])); err != nil {
+		return SpanContext{}, false
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, false
+	}
+
+	sc.Sampled = flags[0]&0x01 == 1
+	sc.Remote = true
+	sc.TraceState = strings.Join(h.Values("tracestate"), ",")
+	return sc, true
+}
+
+// Inject writes the current span from ctx as traceparent and tracestate
+// headers, so an outgoing request continues the trace.
+func Inject(ctx context.Context, h http.Header) {
+	sc := SpanFromContext(ctx).SpanContext()
+	if !sc.IsValid() {
+		return
+	}
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	h.Set("traceparent", "00-"+sc.TraceID.String()+"-"+sc.SpanID.String()+"-"+flags)
+	if sc.TraceState != "" {
+		h.Set("tracestate", sc.TraceState)
+	}
+}
@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceparent string
+		wantOK      bool
+		wantSampled bool
+	}{
+		{"sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"not sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		{"future version with extra field", "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-what", true, true},
+		{"missing", "", false, false},
+		{"version ff", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"version 00 with extra field", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-x", false, false},
+		{"zero trace ID", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"zero span ID", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		{"uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false, false},
+		{"not hex", "00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01", false, false},
+		{"short", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			if tt.traceparent != "" {
+				h.Set("traceparent", tt.traceparent)
+			}
+			h.Add("tracestate", "a=1")
+			h.Add("tracestate", "b=2")
+			sc, ok := Extract(h)
+			if ok != tt.wantOK {
+				t.Fatalf("Extract() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if sc.Sampled != tt.wantSampled || !sc.Remote || sc.TraceState != "a=1,b=2" {
+				t.Errorf("Extract() = %+v", sc)
+			}
+		})
+	}
+}
+
+func TestInjectRoundTrip(t *testing.T) {
+	h := http.Header{}
+	Inject(context.Background(), h)
+	if len(h) != 0 {
+		t.Errorf("Inject() without a span set %v", h)
+	}
+
+	want := SpanContext{TraceID: TraceID{0xab, 1}, SpanID: SpanID{0xcd, 2}, Sampled: true, TraceState: "v=1"}
+	Inject(ContextWithSpan(context.Background(), &Span{sc: want}), h)
+	if got := h.Get("traceparent"); got != "00-ab010000000000000000000000000000-cd02000000000000-01" {
+		t.Errorf("traceparent = %q", got)
+	}
+	got, ok := Extract(h)
+	want.Remote = true
+	if !ok || got != want {
+		t.Errorf("Extract(Inject()) = %+v, %v, want %+v", got, ok, want)
+	}
+}
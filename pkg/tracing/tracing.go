@@ -0,0 +1,455 @@
+// Package tracing records spans compatible with OpenTelemetry: trace and span
+// IDs follow the W3C Trace Context format, incoming traceparent headers are
+// continued, and finished spans are batched to an Exporter such as a JSON
+// writer or an OTLP/HTTP collector.
+//
+// Code creates spans with Start and ends them with End. When no tracer is
+// installed with SetDefault, or a trace is not sampled, spans are no-ops, so
+// instrumentation can stay in place with tracing turned off.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TraceID identifies a trace across services.
+type TraceID [16]byte
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+// SpanID identifies one span within a trace.
+type SpanID [8]byte
+
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+// SpanContext is the part of a span that propagates to children and to
+// other services.
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Sampled    bool
+	Remote     bool
+	TraceState string
+}
+
+func (sc SpanContext) IsValid() bool { return sc.TraceID.IsValid() && sc.SpanID.IsValid() }
+
+// SpanKind uses the OTLP numbering.
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// StatusCode uses the OTLP numbering.
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Attribute is a key and a string, int64, float64 or bool value.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+func String(key, value string) Attribute { return Attribute{key, value} }
+
+func Int(key string, value int) Attribute { return Attribute{key, int64(value)} }
+
+func Int64(key string, value int64) Attribute { return Attribute{key, value} }
+
+func Float64(key string, value float64) Attribute { return Attribute{key, value} }
+
+func Bool(key string, value bool) Attribute { return Attribute{key, value} }
+
+// SpanData is a finished span as handed to an Exporter.
+type SpanData struct {
+	Name          string
+	SpanContext   SpanContext
+	Parent        SpanID
+	Kind          SpanKind
+	Start         time.Time
+	End           time.Time
+	Attributes    []Attribute
+	Status        StatusCode
+	StatusMessage string
+}
+
+// Exporter sends finished spans to a backend.
+type Exporter interface {
+	ExportSpans(ctx context.Context, spans []SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+// Span is an operation being timed. A nil or non-recording span accepts
+// every call and records nothing.
+type Span struct {
+	tracer *Tracer
+	sc     SpanContext
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// SpanContext returns the IDs that identify s.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// IsRecording reports whether s will be exported.
+func (s *Span) IsRecording() bool {
+	return s != nil && s.tracer != nil && s.sc.Sampled
+}
+
+func (s *Span) SetName(name string) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Name = name
+}
+
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Attributes = append(s.data.Attributes, attrs...)
+}
+
+// SetError marks s failed with err's message. A nil err does nothing.
+func (s *Span) SetError(err error) {
+	if err == nil || !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Status = StatusError
+	s.data.StatusMessage = err.Error()
+}
+
+// End finishes s and queues it for export. Later calls do nothing.
+func (s *Span) End() {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	s.tracer.enqueue(data)
+}
+
+type spanContextKey struct{}
+
+// SpanFromContext returns the current span, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// ContextWithSpan returns ctx with span as the current span.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, span)
+}
+
+var defaultTracer atomic.Pointer[Tracer]
+
+// SetDefault installs t as the tracer for spans started without a traced
+// parent. A nil t turns tracing off.
+func SetDefault(t *Tracer) {
+	defaultTracer.Store(t)
+}
+
+// Default returns the tracer installed by SetDefault, or nil.
+func Default() *Tracer {
+	return defaultTracer.Load()
+}
+
+// Start begins an internal span as a child of the span in ctx, or as a new
+// trace with the default tracer. End the returned span when the operation
+// finishes.
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return start(ctx, name, SpanKindInternal, attrs)
+}
+
+// StartClient is Start for calls to another system, such as a database.
+func StartClient(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return start(ctx, name, SpanKindClient, attrs)
+}
+
+func start(ctx context.Context, name string, kind SpanKind, attrs []Attribute) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	tracer := Default()
+	if parent != nil && parent.tracer != nil {
+		tracer = parent.tracer
+	}
+	return tracer.start(ctx, name, kind, parent.SpanContext(), attrs)
+}
+
+// Config configures New. The zero value disables tracing: New returns a nil
+// Tracer unless Exporter or ExporterName is set.
+type Config struct {
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string `config:"service_name" usage:"service name reported with every span"`
+	// Exporter receives finished spans. When nil, ExporterName picks one.
+	Exporter Exporter
+	// ExporterName is "stdout" for JSON lines on standard output, "otlp"
+	// for OTLP/HTTP, or empty to disable tracing.
+	ExporterName string `config:"exporter" usage:"span exporter: stdout, otlp, or empty to disable tracing"`
+	// Endpoint is the OTLP/HTTP traces URL. Default
+	// http://localhost:4318/v1/traces.
+	Endpoint string `config:"endpoint" usage:"OTLP/HTTP traces URL"`
+	// SampleRatio is the fraction of new traces recorded; traces continued
+	// from a traceparent header follow the caller's decision. Default 1.
+	SampleRatio float64 `config:"sample_ratio" usage:"fraction of new traces to record, from 0 to 1"`
+	// BatchSize default 512, FlushInterval default 5s.
+	BatchSize     int           `config:"batch_size" usage:"spans sent per export"`
+	FlushInterval time.Duration `config:"flush_interval" usage:"maximum delay before queued spans are exported"`
+	// QueueSize bounds spans waiting for export; more are dropped.
+	// Default 2048.
+	QueueSize int
+	// Logger default slog.Default().
+	Logger *slog.Logger
+}
+
+// Tracer creates spans and exports them in batches from a background
+// goroutine. A nil Tracer creates no-op spans.
+type Tracer struct {
+	config    Config
+	threshold uint64
+
+	queue    chan SpanData
+	flush    chan chan struct{}
+	stop     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+	dropped  atomic.Uint64
+}
+
+// New starts a tracer. It returns nil and no error when neither Exporter nor
+// ExporterName is set, so callers can pass the result to SetDefault as is.
+func New(config Config) (*Tracer, error) {
+	if config.Exporter == nil {
+		switch config.ExporterName {
+		case "":
+			return nil, nil
+		case "stdout":
+			config.Exporter = NewJSONExporter(nil)
+		case "otlp":
+			config.Exporter = NewOTLPExporter(OTLPConfig{Endpoint: config.Endpoint, ServiceName: config.ServiceName})
+		default:
+			return nil, fmt.Errorf("unknown trace exporter %q", config.ExporterName)
+		}
+	}
+	if config.SampleRatio == 0 {
+		config.SampleRatio = 1
+	}
+	if config.SampleRatio < 0 || config.SampleRatio > 1 {
+		return nil, fmt.Errorf("trace sample ratio %v is outside 0..1", config.SampleRatio)
+	}
+	if config.BatchSize == 0 {
+		config.BatchSize = 512
+	}
+	if config.FlushInterval == 0 {
+		config.FlushInterval = 5 * time.Second
+	}
+	if config.QueueSize == 0 {
+		config.QueueSize = 2048
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
+	t := &Tracer{
+		config:    config,
+		threshold: math.MaxUint64,
+		queue:     make(chan SpanData, config.QueueSize),
+		flush:     make(chan chan struct{}),
+		stop:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	if config.SampleRatio < 1 {
+		t.threshold = uint64(config.SampleRatio * math.MaxUint64)
+	}
+	go t.run()
+	return t, nil
+}
+
+func (t *Tracer) start(ctx context.Context, name string, kind SpanKind, parent SpanContext, attrs []Attribute) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	sc := SpanContext{SpanID: newSpanID(), TraceState: parent.TraceState}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Sampled = parent.Sampled
+	} else {
+		sc.TraceID = newTraceID()
+		sc.Sampled = t.sample(sc.TraceID)
+	}
+
+	span := &Span{tracer: t, sc: sc}
+	if sc.Sampled {
+		span.data = SpanData{
+			Name:        name,
+			SpanContext: sc,
+			Kind:        kind,
+			Start:       time.Now(),
+			Attributes:  attrs,
+		}
+		if parent.IsValid() {
+			span.data.Parent = parent.SpanID
+		}
+	}
+	return ContextWithSpan(ctx, span), span
+}
+
+// sample decides from the trace ID's low bytes, which are random, so every
+// service sampling at the same ratio agrees on the same traces.
+func (t *Tracer) sample(id TraceID) bool {
+	return t.threshold == math.MaxUint64 || binary.BigEndian.Uint64(id[8:]) < t.threshold
+}
+
+func (t *Tracer) enqueue(data SpanData) {
+	select {
+	case t.queue <- data:
+	default:
+		if t.dropped.Add(1) == 1 {
+			t.config.Logger.Warn("Trace queue full, dropping spans", "queue_size", t.config.QueueSize)
+		}
+	}
+}
+
+func (t *Tracer) run() {
+	defer close(t.stopped)
+
+	ticker := time.NewTicker(t.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, t.config.BatchSize)
+	export := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := t.config.Exporter.ExportSpans(ctx, batch); err != nil {
+			t.config.Logger.Error("Failed to export spans", "spans", len(batch), "error", err)
+		}
+		batch = batch[:0]
+	}
+	drain := func() {
+		for {
+			select {
+			case data := <-t.queue:
+				batch = append(batch, data)
+				if len(batch) >= t.config.BatchSize {
+					export()
+				}
+			default:
+				export()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case data := <-t.queue:
+			batch = append(batch, data)
+			if len(batch) >= t.config.BatchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		case done := <-t.flush:
+			drain()
+			close(done)
+		case <-t.stop:
+			drain()
+			return
+		}
+	}
+}
+
+// ForceFlush exports every span ended so far.
+func (t *Tracer) ForceFlush(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	done := make(chan struct{})
+	select {
+	case t.flush <- done:
+	case <-t.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown exports queued spans and shuts the exporter down. Spans ended
+// afterwards are dropped.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	t.stopOnce.Do(func() { close(t.stop) })
+	select {
+	case <-t.stopped:
+	case <-ctx.Done():
+		return errors.Join(ctx.Err(), t.config.Exporter.Shutdown(ctx))
+	}
+	return t.config.Exporter.Shutdown(ctx)
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	rand.Read(id[:])
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	rand.Read(id[:])
+	return id
+}
@@ -0,0 +1,208 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder keeps exported spans for inspection.
+type recorder struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+func (r *recorder) ExportSpans(ctx context.Context, spans []SpanData) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func (r *recorder) Shutdown(ctx context.Context) error { return nil }
+
+func (r *recorder) get() []SpanData {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]SpanData(nil), r.spans...)
+}
+
+func newTestTracer(t *testing.T, config Config) (*Tracer, *recorder) {
+	t.Helper()
+	rec := &recorder{}
+	config.Exporter = rec
+	tracer, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { tracer.Shutdown(context.Background()) })
+	return tracer, rec
+}
+
+func TestNewDisabled(t *testing.T) {
+	tracer, err := New(Config{})
+	if tracer != nil || err != nil {
+		t.Fatalf("New(Config{}) = %v, %v, want nil, nil", tracer, err)
+	}
+
+	// A nil tracer hands out nil spans that accept every call
+	ctx, span := tracer.start(context.Background(), "op", SpanKindInternal, SpanContext{}, nil)
+	span.SetAttributes(String("k", "v"))
+	span.SetError(errors.New("boom"))
+	span.End()
+	if span.IsRecording() || SpanFromContext(ctx) != nil {
+		t.Errorf("nil tracer started a recording span")
+	}
+
+	if _, err := New(Config{ExporterName: "zipkin"}); err == nil {
+		t.Errorf("New() with unknown exporter error = nil")
+	}
+	if _, err := New(Config{ExporterName: "stdout", SampleRatio: 2}); err == nil {
+		t.Errorf("New() with sample ratio 2 error = nil")
+	}
+}
+
+func TestParentLinkage(t *testing.T) {
+	tracer, rec := newTestTracer(t, Config{})
+	SetDefault(tracer)
+	t.Cleanup(func() { SetDefault(nil) })
+
+	ctx, root := Start(context.Background(), "root")
+	_, child := StartClient(ctx, "child", String("db.system.name", "sqlite"))
+	child.SetError(errors.New("no such table"))
+	child.End()
+	child.End()
+	root.End()
+	tracer.ForceFlush(context.Background())
+
+	spans := rec.get()
+	if len(spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(spans))
+	}
+	c, r := spans[0], spans[1]
+	if c.SpanContext.TraceID != r.SpanContext.TraceID {
+		t.Errorf("child trace ID = %v, want %v", c.SpanContext.TraceID, r.SpanContext.TraceID)
+	}
+	if c.Parent != r.SpanContext.SpanID || r.Parent.IsValid() {
+		t.Errorf("parents = %v, %v, want %v and none", c.Parent, r.Parent, r.SpanContext.SpanID)
+	}
+	if c.Kind != SpanKindClient || c.Status != StatusError || c.StatusMessage != "no such table" {
+		t.Errorf("child = %+v, want failed client span", c)
+	}
+	if len(c.Attributes) != 1 || c.Attributes[0].Value != "sqlite" {
+		t.Errorf("child attributes = %v", c.Attributes)
+	}
+}
+
+func TestSampling(t *testing.T) {
+	tests := []struct {
+		ratio    float64
+		min, max int
+	}{
+		{1, 1000, 1000},
+		{0.25, 150, 350},
+		{1e-9, 0, 2},
+	}
+	for _, tt := range tests {
+		tracer, _ := newTestTracer(t, Config{SampleRatio: tt.ratio})
+		sampled := 0
+		for range 1000 {
+			_, span := tracer.start(context.Background(), "op", SpanKindInternal, SpanContext{}, nil)
+			if span.IsRecording() {
+				sampled++
+			}
+		}
+		if sampled < tt.min || sampled > tt.max {
+			t.Errorf("ratio %v sampled %d of 1000, want %d..%d", tt.ratio, sampled, tt.min, tt.max)
+		}
+	}
+
+	// A remote parent's decision wins over the ratio
+	tracer, _ := newTestTracer(t, Config{SampleRatio: 1})
+	parent := SpanContext{TraceID: TraceID{1}, SpanID: SpanID{1}, Remote: true}
+	ctx, span := tracer.start(context.Background(), "op", SpanKindServer, parent, nil)
+	if span.IsRecording() {
+		t.Errorf("span under unsampled parent is recording")
+	}
+	if _, child := Start(ctx, "child"); child.IsRecording() || child.SpanContext().TraceID != parent.TraceID {
+		t.Errorf("child of unsampled span = %+v, want unsampled in the same trace", child.SpanContext())
+	}
+}
+
+func TestBatching(t *testing.T) {
+	tracer, rec := newTestTracer(t, Config{BatchSize: 2, FlushInterval: time.Hour})
+	for range 3 {
+		_, span := tracer.start(context.Background(), "op", SpanKindInternal, SpanContext{}, nil)
+		span.End()
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(rec.get()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := len(rec.get()); got != 2 {
+		t.Fatalf("exported %d spans before flush, want a full batch of 2", got)
+	}
+
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if got := len(rec.get()); got != 3 {
+		t.Errorf("exported %d spans after Shutdown, want 3", got)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	tracer, rec := newTestTracer(t, Config{})
+	mux := http.NewServeMux()
+	var inner SpanContext
+	mux.HandleFunc("GET /posts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		inner = SpanFromContext(r.Context()).SpanContext()
+		http.Error(w, "database locked", http.StatusInternalServerError)
+	})
+	route := func(r *http.Request) string {
+		_, pattern := mux.Handler(r)
+		return pattern
+	}
+	handler := tracer.Middleware(route)(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/posts/7", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	tracer.ForceFlush(context.Background())
+
+	spans := rec.get()
+	if len(spans) != 1 {
+		t.Fatalf("exported %d spans, want 1", len(spans))
+	}
+	s := spans[0]
+	if s.Name != "GET /posts/{id}" {
+		t.Errorf("Name = %q, want %q", s.Name, "GET /posts/{id}")
+	}
+	if s.SpanContext.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || s.Parent.String() != "00f067aa0ba902b7" {
+		t.Errorf("span did not continue the incoming trace: %+v", s)
+	}
+	if inner.SpanID != s.SpanContext.SpanID {
+		t.Errorf("handler saw span %v, want %v", inner.SpanID, s.SpanContext.SpanID)
+	}
+	if s.Status != StatusError {
+		t.Errorf("Status = %v, want StatusError for a 500", s.Status)
+	}
+	attrs := map[string]any{}
+	for _, a := range s.Attributes {
+		attrs[a.Key] = a.Value
+	}
+	if attrs["http.response.status_code"] != int64(500) || attrs["http.route"] != "/posts/{id}" {
+		t.Errorf("attributes = %v", attrs)
+	}
+
+	// A nil tracer leaves the handler untouched
+	var disabled *Tracer
+	if got := disabled.Middleware(nil)(mux); got != http.Handler(mux) {
+		t.Errorf("nil Tracer Middleware() wrapped the handler")
+	}
+}
@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultSessionSecret is the development fallback secret. Keyrings that
+// contain it must never be used in production.
+const DefaultSessionSecret = "default-secret-key-change-in-production"
+
+// SigningKey is one HMAC secret identified by a short key ID.
+type SigningKey struct {
+	ID     string
+	Secret string
+}
+
+// Keyring holds the keys used to sign and verify session tokens. The first
+// key is the newest and is the only one used for signing; the rest are
+// accepted for verification so secrets can be rotated without logging
+// every user out.
+type Keyring struct {
+	keys []SigningKey
+}
+
+// NewKeyring returns a keyring with keys ordered newest first. Keys without
+// an ID get one derived from their secret.
+func NewKeyring(keys ...SigningKey) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("keyring requires at least one key")
+	}
+
+	seen := make(map[string]bool, len(keys))
+	ring := &Keyring{}
+	for _, key := range keys {
+		if key.Secret == "" {
+			return nil, fmt.Errorf("keyring key %q has an empty secret", key.ID)
+		}
+		if key.ID == "" {
+			key.ID = deriveKeyID(key.Secret)
+		}
+		if strings.ContainsAny(key.ID, ".:,") {
+			return nil, fmt.Errorf("keyring key ID %q contains a reserved character", key.ID)
+		}
+		if seen[key.ID] {
+			return nil, fmt.Errorf("keyring has duplicate key ID %q", key.ID)
+		}
+		seen[key.ID] = true
+		ring.keys = append(ring.keys, key)
+	}
+
+	return ring, nil
+}
+
+// ParseKeyring parses a comma- or newline-separated list of "id:secret" or
+// bare "secret" entries, newest first, so the contents of a keyring file are
+// accepted too.
+func ParseKeyring(spec string) (*Keyring, error) {
+	return parseKeyEntries(strings.FieldsFunc(spec, func(r rune) bool {
+		return r == ',' || r == '\n'
+	}))
+}
+
+// LoadKeyringFile reads one "id:secret" or bare "secret" entry per line,
+// newest first. Blank lines and lines starting with # are ignored.
+func LoadKeyringFile(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keyring file: %w", err)
+	}
+	return parseKeyEntries(strings.Split(string(data), "\n"))
+}
+
+func parseKeyEntries(entries []string) (*Keyring, error) {
+	var keys []SigningKey
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		if id, secret, ok := strings.Cut(entry, ":"); ok {
+			keys = append(keys, SigningKey{ID: strings.TrimSpace(id), Secret: strings.TrimSpace(secret)})
+		} else {
+			keys = append(keys, SigningKey{Secret: entry})
+		}
+	}
+
+	return NewKeyring(keys...)
+}
+
+func deriveKeyID(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:4])
+}
+
+// Primary returns the key used for signing.
+func (k *Keyring) Primary() SigningKey {
+	return k.keys[0]
+}
+
+// Lookup returns the key with the given ID.
+func (k *Keyring) Lookup(id string) (SigningKey, bool) {
+	for _, key := range k.keys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+	return SigningKey{}, false
+}
+
+// ContainsSecret reports whether any key uses secret.
+func (k *Keyring) ContainsSecret(secret string) bool {
+	for _, key := range k.keys {
+		if hmac.Equal([]byte(key.Secret), []byte(secret)) {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateSessionToken signs a new token for userID with the primary key.
+func (k *Keyring) CreateSessionToken(userID int, expirationHours int) (string, error) {
+	return k.SignSessionToken(SessionToken{
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(time.Duration(expirationHours) * time.Hour).Unix(),
+	})
+}
+
+// SignSessionToken signs token with the primary key. The result has the
+// form <key id>.<payload>.<signature>.
+func (k *Keyring) SignSessionToken(token SessionToken) (string, error) {
+	key := k.Primary()
+	payload, err := encodeSessionToken(token)
+	if err != nil {
+		return "", err
+	}
+
+	signature := createSignature(key.ID+"."+payload, key.Secret)
+	return fmt.Sprintf("%s.%s.%s", key.ID, payload, signature), nil
+}
+
+// ValidateSessionToken verifies a token signed by any key in the ring. Tokens
+// issued before key IDs existed (<payload>.<signature>) are checked against
+// every key so they keep working through the upgrade.
+func (k *Keyring) ValidateSessionToken(tokenString string) (*SessionToken, error) {
+	parts := strings.Split(tokenString, ".")
+
+	switch len(parts) {
+	case 3:
+		key, ok := k.Lookup(parts[0])
+		if !ok {
+			return nil, fmt.Errorf("unknown token key")
+		}
+		expected := createSignature(parts[0]+"."+parts[1], key.Secret)
+		if !hmac.Equal([]byte(parts[2]), []byte(expected)) {
+			return nil, fmt.Errorf("invalid token signature")
+		}
+		return decodeSessionToken(parts[1])
+
+	case 2:
+		for _, key := range k.keys {
+			expected := createSignature(parts[0], key.Secret)
+			if hmac.Equal([]byte(parts[1]), []byte(expected)) {
+				return decodeSessionToken(parts[0])
+			}
+		}
+		return nil, fmt.Errorf("invalid token signature")
+
+	default:
+		return nil, fmt.Errorf("invalid token format")
+	}
+}
+
+func encodeSessionToken(token SessionToken) (string, error) {
+	if token.Nonce == "" {
+		nonce, err := SecureRandomHex(16)
+		if err != nil {
+			return "", err
+		}
+		token.Nonce = nonce
+	}
+
+	tokenJSON, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.URLEncoding.EncodeToString(tokenJSON), nil
+}
+
+func decodeSessionToken(tokenB64 string) (*SessionToken, error) {
+	tokenJSON, err := base64.URLEncoding.DecodeString(tokenB64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token encoding")
+	}
+
+	var token SessionToken
+	if err := json.Unmarshal(tokenJSON, &token); err != nil {
+		return nil, fmt.Errorf("invalid token data")
+	}
+
+	if time.Now().Unix() > token.ExpiresAt {
+		return nil, fmt.Errorf("token expired")
+	}
+
+	return &token, nil
+}
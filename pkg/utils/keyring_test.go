@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeyringRotation(t *testing.T) {
+	oldRing, err := ParseKeyring("k1:old-secret")
+	if err != nil {
+		t.Fatalf("ParseKeyring() error = %v", err)
+	}
+	token, err := oldRing.CreateSessionToken(42, 1)
+	if err != nil {
+		t.Fatalf("CreateSessionToken() error = %v", err)
+	}
+
+	// After rotation the new key signs, the old key still verifies
+	rotated, _ := ParseKeyring("k2:new-secret,k1:old-secret")
+	if got, err := rotated.ValidateSessionToken(token); err != nil || got.UserID != 42 {
+		t.Errorf("ValidateSessionToken() after rotation = %v, %v, want user 42", got, err)
+	}
+
+	newToken, _ := rotated.CreateSessionToken(7, 1)
+	if _, err := oldRing.ValidateSessionToken(newToken); err == nil {
+		t.Errorf("ValidateSessionToken() with unknown key ID error = nil, want error")
+	}
+
+	// Once the old key is retired its tokens are rejected
+	retired, _ := ParseKeyring("k2:new-secret")
+	if _, err := retired.ValidateSessionToken(token); err == nil {
+		t.Errorf("ValidateSessionToken() with retired key error = nil, want error")
+	}
+}
+
+func TestKeyringAcceptsLegacyTokens(t *testing.T) {
+	legacy, err := CreateSessionToken(5, "old-secret", 1)
+	if err != nil {
+		t.Fatalf("CreateSessionToken() error = %v", err)
+	}
+
+	ring, _ := ParseKeyring("new-secret,old-secret")
+	if got, err := ring.ValidateSessionToken(legacy); err != nil || got.UserID != 5 {
+		t.Errorf("ValidateSessionToken(legacy) = %v, %v, want user 5", got, err)
+	}
+}
+
+func TestLoadKeyringFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys")
+	os.WriteFile(path, []byte("# newest first\nk2:new-secret\n\nk1:old-secret\n"), 0600)
+
+	ring, err := LoadKeyringFile(path)
+	if err != nil {
+		t.Fatalf("LoadKeyringFile() error = %v", err)
+	}
+	if ring.Primary().ID != "k2" {
+		t.Errorf("Primary().ID = %v, want %v", ring.Primary().ID, "k2")
+	}
+	if _, ok := ring.Lookup("k1"); !ok {
+		t.Errorf("Lookup(k1) = false, want true")
+	}
+
+	parsed, err := ParseKeyring("# newest first\nk2:new-secret\nk1:old-secret")
+	if err != nil || parsed.Primary().ID != "k2" {
+		t.Errorf("ParseKeyring(file contents) = %v, %v, want primary k2", parsed, err)
+	}
+}
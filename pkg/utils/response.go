@@ -31,29 +31,29 @@ func Success(w http.ResponseWriter, data interface{}) {
 
 type SessionToken struct {
 	UserID    int    `json:"user_id"`
+	SessionID string `json:"sid,omitempty"`
 	ExpiresAt int64  `json:"expires_at"`
 	Nonce     string `json:"nonce"`
 }
 
+// CreateSessionToken signs a token with a single secret and no key ID.
+// Use a Keyring when secrets need to be rotated.
 func CreateSessionToken(userID int, secretKey string, expirationHours int) (string, error) {
 	expiresAt := time.Now().Add(time.Duration(expirationHours) * time.Hour).Unix()
-	nonce, err := SecureRandomHex(16)
-	if err != nil {
-		return "", err
-	}
-
-	token := SessionToken{
+	return SignSessionToken(SessionToken{
 		UserID:    userID,
 		ExpiresAt: expiresAt,
-		Nonce:     nonce,
-	}
+	}, secretKey)
+}
 
-	tokenJSON, err := json.Marshal(token)
+// SignSessionToken encodes and signs a token built by the caller, for example
+// one that references a server-side session by SessionID.
+func SignSessionToken(token SessionToken, secretKey string) (string, error) {
+	tokenB64, err := encodeSessionToken(token)
 	if err != nil {
 		return "", err
 	}
 
-	tokenB64 := base64.URLEncoding.EncodeToString(tokenJSON)
 	signature := createSignature(tokenB64, secretKey)
 
 	return fmt.Sprintf("%s.%s", tokenB64, signature), nil
@@ -72,21 +72,7 @@ func ValidateSessionToken(tokenString string, secretKey string) (*SessionToken,
 		return nil, fmt.Errorf("invalid token signature")
 	}
 
-	tokenJSON, err := base64.URLEncoding.DecodeString(tokenB64)
-	if err != nil {
-		return nil, fmt.Errorf("invalid token encoding")
-	}
-
-	var token SessionToken
-	if err := json.Unmarshal(tokenJSON, &token); err != nil {
-		return nil, fmt.Errorf("invalid token data")
-	}
-
-	if time.Now().Unix() > token.ExpiresAt {
-		return nil, fmt.Errorf("token expired")
-	}
-
-	return &token, nil
+	return decodeSessionToken(tokenB64)
 }
 
 func createSignature(data, secretKey string) string {
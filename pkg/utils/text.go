@@ -4,6 +4,7 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"unicode/utf8"
 )
 
 type TextAnalysis struct {
@@ -181,6 +182,16 @@ func ValidateDisplayName(displayName string) *ValidationError {
 	return nil
 }
 
+// ValidateBio allows an empty bio; it counts characters, not bytes, so
+// non-Latin bios get the same room.
+func ValidateBio(bio string) *ValidationError {
+	if utf8.RuneCountInString(bio) > 160 {
+		return &ValidationError{Field: "bio", Message: "Bio must be at most 160 characters"}
+	}
+
+	return nil
+}
+
 func ValidatePostContent(content string) *ValidationError {
 	if content == "" {
 		return &ValidationError{Field: "content", Message: "Post content cannot be empty"}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -48,3 +49,24 @@ func TestIsPalindrome(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateBio(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"empty bio", "", false},
+		{"a short bio", "Writes about Go", false},
+		{"160 multibyte characters", strings.Repeat("é", 160), false},
+		{"161 characters", strings.Repeat("a", 161), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidateBio(tt.input); (got != nil) != tt.wantErr {
+				t.Errorf("ValidateBio() = %v, wantErr %v", got, tt.wantErr)
+			}
+		})
+	}
+}